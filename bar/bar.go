@@ -4,8 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
-
-	"github.com/pterm/pterm"
+	"time"
 )
 
 type State int
@@ -33,20 +32,49 @@ func (b State) String() string {
 }
 
 type Bar struct {
-	multiBar MultiPrinter
-	barMap   sync.Map
+	renderer renderer
 	event    sync.Map
 	name     string
+	mux      sync.Mutex
+	steps    map[string]*step
+	roots    []string
+	subMux   sync.Mutex
+	subs     map[*subscriber]struct{}
+	stopped  bool
+	root     step   // 根 bar 的执行记录
+	report   string // Stop 时写入报告的文件
 }
 
 func NewBar(name string) *Bar {
+	return NewBarWithMode(name, ModeAuto)
+}
+
+// NewBarWithMode
+//
+//	@Description: 指定输出模式创建 bar，ModeAuto 在非终端下输出纯文本日志
+//	@param name
+//	@param mode
+//	@return *Bar
+func NewBarWithMode(name string, mode Mode) *Bar {
+	return New(name, WithMode(mode))
+}
+
+// New
+//
+//	@Description: 创建 bar，每个 bar 使用独立的输出，可同时存在多个
+//	@param name
+//	@param opts WithWriter、WithUpdateDelay、WithTheme、WithMode、WithReportFile
+//	@return *Bar
+func New(name string, opts ...Option) *Bar {
+	o := newOptions(opts...)
 	b := &Bar{
-		multiBar: DefaultMultiPrinter,
-		barMap:   sync.Map{},
-		event:    sync.Map{},
-		name:     name,
+		event:  sync.Map{},
+		name:   name,
+		steps:  make(map[string]*step),
+		root:   step{name: name, start: time.Now()},
+		report: o.reportFile,
 	}
-	b.run()
+	b.renderer = newRenderer(name, o)
 	return b
 }
 
@@ -57,14 +85,22 @@ func NewBar(name string) *Bar {
 //	@param barName
 //	@param startMsg
 func (b *Bar) AddStartBar(barName, startMsg string) {
-	if br := b.mapLoad(barName); br != nil {
-		bar, _ := br.WithWriter(b.multiBar.NewWriter()).Start(startMsg)
-		b.barMap.Store(barName, bar)
-		return
-	}
-	bar, _ := pterm.DefaultSpinner.WithWriter(b.multiBar.NewWriter()).Start(startMsg)
+	b.AddStartSubBar("", barName, startMsg)
+}
 
-	b.barMap.Store(barName, bar)
+// AddStartSubBar
+//
+//	@Description: 在 parentName 下添加一个子 bar，子 bar 会缩进显示；父 bar 结束且所有子 bar 都结束后，子 bar 会折叠
+//	@receiver b
+//	@param parentName 为空或不存在时等同于 AddStartBar
+//	@param barName
+//	@param startMsg
+func (b *Bar) AddStartSubBar(parentName, barName, startMsg string) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	s := b.startStep(parentName, barName, startMsg)
+	b.renderer.start(barName, s.parent, startMsg, s.depth)
+	b.publish(Event{Type: EventStart, Name: barName, Parent: s.parent, Msg: startMsg})
 }
 
 // Info
@@ -73,7 +109,10 @@ func (b *Bar) AddStartBar(barName, startMsg string) {
 //	@receiver b
 //	@param info
 func (b *Bar) Info(info string) {
-	pterm.DefaultSpinner.WithWriter(b.multiBar.NewWriter()).Info(info)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.renderer.print(info, StateInfo)
+	b.publish(Event{Type: EventPrint, Msg: info, State: StateInfo})
 }
 
 // Warning
@@ -82,7 +121,10 @@ func (b *Bar) Info(info string) {
 //	@receiver b
 //	@param warning
 func (b *Bar) Warning(warning string) {
-	pterm.DefaultSpinner.WithWriter(b.multiBar.NewWriter()).Warning(warning)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.renderer.print(warning, StateWarning)
+	b.publish(Event{Type: EventPrint, Msg: warning, State: StateWarning})
 }
 
 // Fail
@@ -91,7 +133,10 @@ func (b *Bar) Warning(warning string) {
 //	@receiver b
 //	@param fail
 func (b *Bar) Fail(fail string) {
-	pterm.DefaultSpinner.WithWriter(b.multiBar.NewWriter()).Fail(fail)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.renderer.print(fail, StateFail)
+	b.publish(Event{Type: EventPrint, Msg: fail, State: StateFail})
 }
 
 // Success
@@ -100,7 +145,10 @@ func (b *Bar) Fail(fail string) {
 //	@receiver b
 //	@param success
 func (b *Bar) Success(success string) {
-	pterm.DefaultSpinner.WithWriter(b.multiBar.NewWriter()).Success(success)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.renderer.print(success, StateSuccess)
+	b.publish(Event{Type: EventPrint, Msg: success, State: StateSuccess})
 }
 
 // StopStartBar
@@ -109,17 +157,18 @@ func (b *Bar) Success(success string) {
 //	@receiver b
 //	@param barName
 func (b *Bar) StopStartBar(barName string) {
-	value, ok := b.barMap.Load(barName)
-	if ok {
-		//nolint:forcetypeassert
-		_ = value.(*pterm.SpinnerPrinter).Stop()
-		return
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	if s, ok := b.steps[barName]; ok && s.end.IsZero() {
+		s.end = time.Now()
 	}
+	b.renderer.stopBar(barName)
+	b.publish(Event{Type: EventStop, Name: barName})
 }
 
 // Stop
 //
-//	@Description: 停止 bar
+//	@Description: 停止 bar，并输出所有 step 的状态与耗时汇总；设置了 WithReportFile 时写入报告
 //	@receiver b
 //
 //nolint:forcetypeassert
@@ -132,12 +181,27 @@ func (b *Bar) Stop() {
 		}
 		return true
 	})
-	if len(msg) == 0 {
-		b.mapLoad(b.name).Success(b.name+": ", "执行完成")
-	} else {
-		b.mapLoad(b.name).Warning(b.name+": ", strings.Join(msg, ", "))
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	stopMsg, state := b.name+": 执行完成", StateSuccess
+	if len(msg) > 0 {
+		stopMsg, state = b.name+": "+strings.Join(msg, ", "), StateWarning
 	}
-	_, _ = b.multiBar.Stop()
+	if b.renderer.setState(b.name, stopMsg, state) {
+		b.publish(Event{Type: EventState, Name: b.name, Msg: stopMsg, State: state})
+	}
+	b.root.state = state
+	b.root.end = time.Now()
+	b.root.msgs = append(b.root.msgs, stopMsg)
+	if b.report != "" {
+		if err := b.newReport().WriteFile(b.report); err != nil {
+			b.renderer.print(err.Error(), StateFail)
+		}
+	}
+	b.renderer.stop()
+	b.renderer.summary(b.sortedSteps())
+	b.publish(Event{Type: EventStop})
+	b.closeSubs()
 }
 
 // SetBarState
@@ -148,24 +212,31 @@ func (b *Bar) Stop() {
 //	@param msg
 //	@param barState
 func (b *Bar) SetBarState(barName, msg string, barState State) {
-	if b.mapLoad(barName) != nil {
-		switch barState {
-		case StateInfo:
-			b.mapLoad(barName).Info(msg)
-			b.event.Store(msg, StateInfo)
-		case StateWarning:
-			b.mapLoad(barName).Warning(msg)
-			b.event.Store(msg, StateWarning)
-		case StateFail:
-			b.mapLoad(barName).Fail(msg)
-			b.event.Store(msg, StateFail)
-		case StateSuccess:
-			b.mapLoad(barName).Success(msg)
-			b.event.Store(msg, StateSuccess)
-		}
-		_ = b.mapLoad(barName).Stop()
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	s, ok := b.steps[barName]
+	if ok && len(s.children) > 0 {
+		msg = elapsedMsg(msg, time.Since(s.start))
+	}
+	if !b.renderer.setState(barName, msg, barState) {
 		return
 	}
+	b.event.Store(msg, barState)
+	b.publish(Event{Type: EventState, Name: barName, Parent: b.parentOf(barName), Msg: msg, State: barState})
+	if !ok {
+		return
+	}
+	s.state = barState
+	s.end = time.Now()
+	s.msgs = append(s.msgs, msg)
+
+	// 分组及其子 bar 都结束后折叠子 bar
+	for s != nil {
+		if len(s.children) > 0 && b.finished(s) {
+			b.renderer.collapse(b.descendants(s))
+		}
+		s = b.steps[s.parent]
+	}
 }
 
 // UpdateStartBarMsg
@@ -175,33 +246,8 @@ func (b *Bar) SetBarState(barName, msg string, barState State) {
 //	@param barName
 //	@param msg
 func (b *Bar) UpdateStartBarMsg(barName, msg string) {
-	if b.mapLoad(barName) != nil {
-		b.mapLoad(barName).UpdateText(msg)
-		return
-	}
-}
-
-var once sync.Once
-
-// run
-//
-//	@Description:运行bar
-//	@receiver b
-func (b *Bar) run() {
-	once.Do(func() {
-		b.Info(b.name)
-	})
-	start, _ := pterm.DefaultSpinner.WithWriter(b.multiBar.Writer).Start(fmt.Sprintf("%s...", b.name))
-
-	_, _ = b.multiBar.Start()
-	b.barMap.Store(b.name, start)
-}
-
-//nolint:forcetypeassert
-func (b *Bar) mapLoad(name string) *pterm.SpinnerPrinter {
-	value, ok := b.barMap.Load(name)
-	if !ok {
-		return nil
-	}
-	return value.(*pterm.SpinnerPrinter)
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.renderer.update(barName, msg)
+	b.publish(Event{Type: EventUpdate, Name: barName, Parent: b.parentOf(barName), Msg: msg})
 }
@@ -1,8 +1,12 @@
 package bar
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -28,3 +32,293 @@ func TestNewBar(t *testing.T) {
 	}
 	bar.Stop()
 }
+
+func TestPlainRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "服务检查", steps: make(map[string]*step), renderer: newPlainRenderer("服务检查", &buf, false)}
+	bar.AddStartBar("docker", "检查： docker...")
+	bar.UpdateStartBarMsg("docker", "检查： docker 运行中")
+	bar.SetBarState("docker", "docker：OK", StateSuccess)
+	bar.SetBarState("unknown", "unknown：OK", StateSuccess)
+	bar.AddStartBar("redis", "检查： redis...")
+	bar.SetBarState("redis", "redis：Fail", StateFail)
+	bar.Stop()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"[START] 服务检查: 服务检查...",
+		"[START] 服务检查/docker: 检查： docker...",
+		"[UPDATE] 服务检查/docker: 检查： docker 运行中",
+		"[SUCCESS] 服务检查/docker: docker：OK",
+		"[START] 服务检查/redis: 检查： redis...",
+		"[FAIL] 服务检查/redis: redis：Fail",
+		"[WARNING] 服务检查: 服务检查: redis：Fail:Fail",
+		"[SUMMARY] 服务检查/docker: Success 0s docker：OK",
+		"[SUMMARY] 服务检查/redis: Fail 0s redis：Fail",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("lines = %q", lines)
+	}
+	for i := range want {
+		if !strings.HasSuffix(lines[i], want[i]) {
+			t.Errorf("line %d = %q, want suffix %q", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestPlainRendererJSON(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "服务检查", steps: make(map[string]*step), renderer: newPlainRenderer("服务检查", &buf, true)}
+	bar.AddStartBar("docker", "检查： docker...")
+	bar.SetBarState("docker", "docker：OK", StateSuccess)
+	bar.Stop()
+
+	var line plainLine
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if err := json.Unmarshal([]byte(lines[2]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Bar != "服务检查" || line.Name != "docker" || line.Event != "success" || line.Msg != "docker：OK" {
+		t.Errorf("line = %+v", line)
+	}
+}
+
+func TestSubBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "部署", steps: make(map[string]*step), renderer: newPlainRenderer("部署", &buf, false)}
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.AddStartSubBar("k8s", "etcd", "部署 etcd...")
+	bar.AddStartSubBar("etcd", "etcd-1", "部署 etcd-1...")
+	bar.SetBarState("etcd-1", "etcd-1：OK", StateSuccess)
+	bar.SetBarState("etcd", "etcd：OK", StateSuccess)
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar.Stop()
+
+	out := buf.String()
+	for _, want := range []string{
+		"[START] 部署/k8s/etcd: 部署 etcd...",
+		"[START] 部署/k8s/etcd/etcd-1: 部署 etcd-1...",
+		"[SUCCESS] 部署/k8s/etcd/etcd-1: etcd-1：OK\n",
+		"[SUCCESS] 部署/k8s/etcd: etcd：OK (耗时 ",
+		"[SUMMARY] 部署/k8s/etcd/etcd-1: Success",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	steps := bar.sortedSteps()
+	var names []string
+	for _, s := range steps {
+		names = append(names, fmt.Sprintf("%s:%d", s.name, s.depth))
+	}
+	if got := strings.Join(names, ","); got != "k8s:0,etcd:1,etcd-1:2" {
+		t.Errorf("steps = %s", got)
+	}
+}
+
+func TestSubBarJSON(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "部署", steps: make(map[string]*step), renderer: newPlainRenderer("部署", &buf, true)}
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.AddStartSubBar("k8s", "etcd", "部署 etcd...")
+
+	var line plainLine
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if err := json.Unmarshal([]byte(lines[2]), &line); err != nil {
+		t.Fatal(err)
+	}
+	if line.Name != "etcd" || line.Parent != "k8s" {
+		t.Errorf("line = %+v", line)
+	}
+}
+
+func TestSubBarCollapse(t *testing.T) {
+	bar := NewBarWithMode("部署", ModeTerminal)
+	r := bar.renderer.(*terminalRenderer)
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.AddStartSubBar("k8s", "etcd", "部署 etcd...")
+	bar.AddStartSubBar("k8s", "apiserver", "部署 apiserver...")
+	count := func() int {
+		defer r.multiBar.lock()()
+		return len(r.multiBar.buffers)
+	}
+	before := count()
+	bar.SetBarState("etcd", "etcd：OK", StateSuccess)
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	if got := count(); got != before {
+		t.Errorf("collapsed before all children finished: %d != %d", got, before)
+	}
+	bar.SetBarState("apiserver", "apiserver：OK", StateSuccess)
+	if got := count(); got != before-2 {
+		t.Errorf("buffers = %d, want %d", got, before-2)
+	}
+	bar.Stop()
+}
+
+func TestSubscribe(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "部署", steps: make(map[string]*step), renderer: newPlainRenderer("部署", &buf, false)}
+	events, cancel := bar.Subscribe()
+	defer cancel()
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.AddStartSubBar("k8s", "etcd", "部署 etcd...")
+	bar.UpdateStartBarMsg("etcd", "部署 etcd 中")
+	bar.SetBarState("etcd", "etcd：OK", StateSuccess)
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar.Info("完成")
+	bar.Stop()
+
+	var got []string
+	for event := range events {
+		if event.Bar != "部署" || event.Time.IsZero() {
+			t.Errorf("event = %+v", event)
+		}
+		got = append(got, fmt.Sprintf("%s %s/%s %s", event.Type, event.Parent, event.Name, event.State))
+	}
+	want := []string{
+		"start /k8s Info",
+		"start k8s/etcd Info",
+		"update k8s/etcd Info",
+		"state k8s/etcd Success",
+		"state /k8s Success",
+		"print / Info",
+		"state / Success",
+		"stop / Info",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+
+	closed, _ := bar.Subscribe()
+	if _, ok := <-closed; ok {
+		t.Error("subscribe after Stop should return a closed channel")
+	}
+}
+
+func TestSubscribeSlowConsumer(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "部署", steps: make(map[string]*step), renderer: newPlainRenderer("部署", &buf, false)}
+	events, cancel := bar.Subscribe()
+	defer cancel()
+	bar.AddStartBar("k8s", "部署 k8s...")
+	// 不读取 channel，积压的 update 会被丢弃，state 和 stop 必须送达
+	for i := range eventBuffer * 2 {
+		bar.UpdateStartBarMsg("k8s", fmt.Sprint(i))
+	}
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar.Stop()
+
+	var count int
+	var last []string
+	for event := range events {
+		count++
+		if event.Type == EventState || event.Type == EventStop {
+			last = append(last, fmt.Sprintf("%s /%s %s", event.Type, event.Name, event.State))
+		}
+	}
+	want := []string{"state /k8s Success", "state / Success", "stop / Info"}
+	if strings.Join(last, "\n") != strings.Join(want, "\n") || count > eventBuffer+len(want) {
+		t.Errorf("count = %d, final events = %q, want %q", count, last, want)
+	}
+}
+
+// lockedBuffer 供并发写入的测试 writer
+type lockedBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
+
+func TestNewWithWriter(t *testing.T) {
+	var out1, out2 lockedBuffer
+	// 写入 DefaultMultiPrinter 的内容不应出现在新的 Bar 中
+	global := DefaultMultiPrinter.NewWriter()
+	defer DefaultMultiPrinter.RemoveWriter(global)
+	_, _ = global.Write([]byte("global writer"))
+	bar1 := New("检查", WithMode(ModeTerminal), WithWriter(&out1), WithUpdateDelay(10*time.Millisecond))
+	bar2 := New("部署", WithMode(ModeTerminal), WithWriter(&out2), WithUpdateDelay(10*time.Millisecond))
+	bar1.AddStartBar("docker", "检查 docker...")
+	bar2.AddStartBar("k8s", "部署 k8s...")
+	time.Sleep(30 * time.Millisecond)
+	bar1.SetBarState("docker", "docker：OK", StateSuccess)
+	bar2.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar1.Stop()
+	bar2.Stop()
+
+	for _, c := range []struct {
+		out        string
+		want, skip string
+	}{
+		{out1.String(), "docker：OK", "k8s"},
+		{out2.String(), "k8s：OK", "docker"},
+	} {
+		if !strings.Contains(c.out, c.want) || strings.Contains(c.out, c.skip) || strings.Contains(c.out, "global writer") {
+			t.Errorf("output = %q, want %q without %q", c.out, c.want, c.skip)
+		}
+	}
+}
+
+func TestNewAutoMode(t *testing.T) {
+	var buf bytes.Buffer
+	bar := New("检查", WithWriter(&buf))
+	if _, ok := bar.renderer.(*plainRenderer); !ok {
+		t.Fatalf("renderer = %T, want *plainRenderer for non-terminal writer", bar.renderer)
+	}
+	bar.Stop()
+	if !strings.Contains(buf.String(), "[START] 检查: 检查...") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{name: "部署", steps: make(map[string]*step), renderer: newPlainRenderer("部署", &buf, false)}
+	events, cancel := bar.Subscribe()
+	defer cancel()
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar.Stop()
+
+	var data []byte
+	for event := range events {
+		if event.Type == EventState {
+			data, _ = json.Marshal(event)
+			break
+		}
+	}
+	// State 保持数字，名称在 stateName 中
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["state"] != float64(StateSuccess) || decoded["stateName"] != "Success" {
+		t.Errorf("event = %s", data)
+	}
+}
+
+func TestMultiPrinterZeroValue(t *testing.T) {
+	var p MultiPrinter
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Go(func() {
+			_, _ = p.NewWriter().Write([]byte("x"))
+		})
+	}
+	wg.Wait()
+	p.lock()()
+	if len(p.buffers) != 4 {
+		t.Errorf("buffers = %d", len(p.buffers))
+	}
+}
@@ -0,0 +1,173 @@
+package bar
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType bar 事件类型
+type EventType string
+
+const (
+	EventStart  EventType = "start"  // bar 开始
+	EventUpdate EventType = "update" // bar 文本更新
+	EventState  EventType = "state"  // bar 设置状态
+	EventPrint  EventType = "print"  // Info、Warning、Fail、Success 输出
+	EventStop   EventType = "stop"   // bar 停止，Name 为空表示整个 Bar 停止
+)
+
+// eventBuffer 每个订阅者最多积压的事件数，超出后丢弃 start、update、print 事件
+const eventBuffer = 256
+
+// Event bar 的状态变化事件
+type Event struct {
+	Type      EventType `json:"type"`
+	Bar       string    `json:"bar"`
+	Name      string    `json:"name,omitempty"`   // 为空表示根 bar
+	Parent    string    `json:"parent,omitempty"` // 子 bar 的父 bar
+	Msg       string    `json:"msg,omitempty"`
+	State     State     `json:"state,omitempty"`     // 仅 EventState、EventPrint
+	StateName string    `json:"stateName,omitempty"` // State 的名称，例如 Success
+	Time      time.Time `json:"time"`
+}
+
+// subscriber 一个订阅者，publish 只写入队列，由 forward 协程按顺序发送到 ch
+type subscriber struct {
+	ch     chan Event
+	mux    sync.Mutex
+	queue  []Event
+	closed bool          // Bar 已停止，发送完队列后关闭 ch
+	signal chan struct{} // 队列有新事件或 closed
+	done   chan struct{} // 取消订阅
+}
+
+// Subscribe
+//
+//	@Description: 订阅 bar 事件，不会阻塞 bar；订阅者消费过慢时 start、update、print 事件会被丢弃，
+//	state 和 stop 事件一定会送达，积压时同一个 bar 只保留最新的一条；Bar 停止并发送完所有事件后 channel 会被关闭
+//	@receiver b
+//	@return <-chan Event
+//	@return func() 取消订阅并关闭 channel，可重复调用
+func (b *Bar) Subscribe() (<-chan Event, func()) {
+	sub := &subscriber{
+		ch:     make(chan Event),
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	b.subMux.Lock()
+	defer b.subMux.Unlock()
+	if b.stopped {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	if b.subs == nil {
+		b.subs = make(map[*subscriber]struct{})
+	}
+	b.subs[sub] = struct{}{}
+	go sub.forward()
+
+	var once sync.Once
+	return sub.ch, func() {
+		once.Do(func() {
+			b.subMux.Lock()
+			delete(b.subs, sub)
+			b.subMux.Unlock()
+			close(sub.done)
+		})
+	}
+}
+
+// publish
+//
+//	@Description: 非阻塞地发送事件给所有订阅者
+//	@receiver b
+//	@param event
+func (b *Bar) publish(event Event) {
+	event.Bar = b.name
+	event.Time = time.Now()
+	if event.State != 0 {
+		event.StateName = event.State.String()
+	}
+	if event.Name == b.name {
+		event.Name = ""
+	}
+	b.subMux.Lock()
+	defer b.subMux.Unlock()
+	for sub := range b.subs {
+		sub.push(event)
+	}
+}
+
+// closeSubs 发送完已有事件后关闭所有订阅，之后的订阅直接返回已关闭的 channel
+func (b *Bar) closeSubs() {
+	b.subMux.Lock()
+	defer b.subMux.Unlock()
+	b.stopped = true
+	for sub := range b.subs {
+		sub.close()
+	}
+	b.subs = nil
+}
+
+// push 将事件加入队列，积压超过 eventBuffer 时丢弃非最终事件，最终事件按 bar 合并
+func (s *subscriber) push(event Event) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.queue) >= eventBuffer {
+		if event.Type != EventState && event.Type != EventStop {
+			return
+		}
+		s.queue = slices.DeleteFunc(s.queue, func(e Event) bool {
+			return e.Type == event.Type && e.Name == event.Name
+		})
+	}
+	s.queue = append(s.queue, event)
+	s.notify()
+}
+
+// close Bar 停止，发送完队列后关闭 ch
+func (s *subscriber) close() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.closed = true
+	s.notify()
+}
+
+// notify 唤醒 forward，调用方需持有锁
+func (s *subscriber) notify() {
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+// forward 按顺序将队列中的事件发送到 ch，Bar 停止且队列为空或取消订阅后关闭 ch
+func (s *subscriber) forward() {
+	defer close(s.ch)
+	for {
+		s.mux.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mux.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-s.signal:
+			case <-s.done:
+				return
+			}
+			continue
+		}
+		event := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mux.Unlock()
+
+		select {
+		case s.ch <- event:
+		case <-s.done:
+			return
+		}
+	}
+}
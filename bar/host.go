@@ -0,0 +1,56 @@
+package bar
+
+import (
+	"fmt"
+	"time"
+)
+
+// HostProgress 每台主机一个 bar 展示多主机执行的进度，实现了 bash.Progress
+type HostProgress struct {
+	bar *Bar
+}
+
+// NewHostProgress
+//
+//	@Description: 创建多主机执行的进度展示
+//	@param b
+//	@return *HostProgress
+func NewHostProgress(b *Bar) *HostProgress {
+	return &HostProgress{bar: b}
+}
+
+// Start
+//
+//	@Description: 主机开始执行命令，添加该主机的 bar
+//	@receiver p
+//	@param host
+//	@param cmd
+func (p *HostProgress) Start(host, cmd string) {
+	p.bar.AddStartBar(host, fmt.Sprintf("%s: %s...", host, cmd))
+}
+
+// Update
+//
+//	@Description: 将最新的一行输出展示到该主机的 bar 上
+//	@receiver p
+//	@param host
+//	@param line
+func (p *HostProgress) Update(host, line string) {
+	p.bar.UpdateStartBarMsg(host, fmt.Sprintf("%s: %s", host, line))
+}
+
+// Done
+//
+//	@Description: 主机执行结束，设置该主机 bar 的状态
+//	@receiver p
+//	@param host
+//	@param exitCode
+//	@param duration
+//	@param err 为 nil 表示成功
+func (p *HostProgress) Done(host string, exitCode int, duration time.Duration, err error) {
+	if err == nil {
+		p.bar.SetBarState(host, fmt.Sprintf("%s: 执行成功, 耗时 %s", host, duration.Round(time.Millisecond)), StateSuccess)
+		return
+	}
+	p.bar.SetBarState(host, fmt.Sprintf("%s: 执行失败, exit.code: %d", host, exitCode), StateFail)
+}
@@ -2,23 +2,31 @@ package bar
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"atomicgo.dev/schedule"
 	"github.com/pterm/pterm"
 )
 
+// defaultUpdateDelay MultiPrinter 默认的刷新间隔
+const defaultUpdateDelay = time.Millisecond * 200
+
 var DefaultMultiPrinter = MultiPrinter{
 	printers:    []pterm.LivePrinter{},
 	Writer:      os.Stdout,
-	UpdateDelay: time.Millisecond * 200,
+	UpdateDelay: defaultUpdateDelay,
 	buffers:     []*bytes.Buffer{},
-	area:        pterm.DefaultArea,
+	mux:         &sync.Mutex{},
 }
 
+// zeroMux 未设置锁的 MultiPrinter（例如零值）共用的锁，避免并发地延迟创建锁
+var zeroMux sync.Mutex
+
 //nolint:recvcheck
 type MultiPrinter struct {
 	IsActive    bool
@@ -27,10 +35,32 @@ type MultiPrinter struct {
 
 	printers []pterm.LivePrinter
 	buffers  []*bytes.Buffer
-	area     pterm.AreaPrinter
+	indents  map[*bytes.Buffer]int
+	area     area
+	mux      *sync.Mutex // 保护 buffers、indents、IsActive 及 NewWriter 返回的 writer
+}
+
+// lock 加锁并返回解锁函数，零值的 MultiPrinter 使用 zeroMux
+func (p *MultiPrinter) lock() func() {
+	mux := p.mux
+	if mux == nil {
+		mux = &zeroMux
+	}
+	mux.Lock()
+	return mux.Unlock
+}
+
+// fork 复制配置，使用新的锁和 buffers，与原 MultiPrinter 互不影响
+func (p MultiPrinter) fork() *MultiPrinter {
+	p.mux = &sync.Mutex{}
+	p.buffers = append([]*bytes.Buffer{}, p.buffers...)
+	p.indents = nil
+	p.area = area{}
+	return &p
 }
 
 func (p *MultiPrinter) AddBuffer(buffer *bytes.Buffer) {
+	defer p.lock()()
 	p.buffers = append(p.buffers, buffer)
 }
 
@@ -46,33 +76,75 @@ func (p *MultiPrinter) SetWriter(writer io.Writer) {
 
 // WithWriter returns a fork of the MultiPrinter with a new writer.
 func (p MultiPrinter) WithWriter(writer io.Writer) *MultiPrinter {
-	p.Writer = writer
-	return &p
+	fork := p.fork()
+	fork.Writer = writer
+	return fork
 }
 
 // WithUpdateDelay returns a fork of the MultiPrinter with a new update delay.
 func (p MultiPrinter) WithUpdateDelay(delay time.Duration) *MultiPrinter {
-	p.UpdateDelay = delay
-	return &p
+	fork := p.fork()
+	fork.UpdateDelay = delay
+	return fork
 }
 
 func (p *MultiPrinter) NewWriter() io.Writer {
+	return p.NewWriterWithIndent(0)
+}
+
+// NewWriterWithIndent
+//
+//	@Description: 与 NewWriter 相同，输出时每行前缀 indent 级缩进
+//	@receiver p
+//	@param indent
+//	@return io.Writer
+func (p *MultiPrinter) NewWriterWithIndent(indent int) io.Writer {
+	defer p.lock()()
 	buf := bytes.NewBufferString("")
 	p.buffers = append(p.buffers, buf)
-	return buf
+	if indent > 0 {
+		if p.indents == nil {
+			p.indents = make(map[*bytes.Buffer]int)
+		}
+		p.indents[buf] = indent
+	}
+	return &bufferWriter{printer: p, buf: buf}
+}
+
+// RemoveWriter
+//
+//	@Description: 移除 NewWriter 创建的 writer，其内容不再显示
+//	@receiver p
+//	@param writer
+func (p *MultiPrinter) RemoveWriter(writer io.Writer) {
+	if w, ok := writer.(*bufferWriter); ok {
+		writer = w.buf
+	}
+	defer p.lock()()
+	for i, buf := range p.buffers {
+		if buf == writer {
+			p.buffers = append(p.buffers[:i:i], p.buffers[i+1:]...)
+			delete(p.indents, buf)
+			return
+		}
+	}
 }
 
 func (p *MultiPrinter) Start() (*MultiPrinter, error) {
+	unlock := p.lock()
 	p.IsActive = true
+	p.area.writer = p.Writer
+	unlock()
 	for _, printer := range p.printers {
 		_, _ = printer.GenericStart()
 	}
 
 	schedule.Every(p.UpdateDelay, func() bool {
+		defer p.lock()()
 		if !p.IsActive {
 			return false
 		}
-		p.area.Update(p.getString())
+		p.area.update(p.getString())
 		return true
 	})
 
@@ -80,13 +152,15 @@ func (p *MultiPrinter) Start() (*MultiPrinter, error) {
 }
 
 func (p *MultiPrinter) Stop() (*MultiPrinter, error) {
+	unlock := p.lock()
 	p.IsActive = false
+	unlock()
 	for _, printer := range p.printers {
 		_, _ = printer.GenericStop()
 	}
 	time.Sleep(time.Millisecond * 20)
-	p.area.Update(p.getString())
-	_ = p.area.Stop()
+	defer p.lock()()
+	p.area.update(p.getString())
 
 	return p, nil
 }
@@ -110,6 +184,7 @@ func (p MultiPrinter) GenericStop() (*pterm.LivePrinter, error) {
 }
 
 // getString returns all buffers appended and separated by a newline.
+// The caller must hold the lock.
 func (p *MultiPrinter) getString() string {
 	var buffer bytes.Buffer
 	for _, b := range p.buffers {
@@ -127,8 +202,41 @@ func (p *MultiPrinter) getString() string {
 		}
 
 		s = strings.Trim(s, "\n\r")
+		buffer.WriteString(strings.Repeat("  ", p.indents[b]))
 		buffer.WriteString(s)
 		buffer.WriteString("\n")
 	}
 	return buffer.String()
 }
+
+// bufferWriter NewWriter 返回的 writer，写入时持有 MultiPrinter 的锁，避免与刷新并发读写 buffer
+type bufferWriter struct {
+	printer *MultiPrinter
+	buf     *bytes.Buffer
+}
+
+func (w *bufferWriter) Write(data []byte) (int, error) {
+	defer w.printer.lock()()
+	return w.buf.Write(data) //nolint:wrapcheck
+}
+
+// area 在 writer 上原地刷新多行内容，与 pterm.AreaPrinter 不同，不固定输出到 os.Stdout
+type area struct {
+	writer io.Writer
+	height int
+}
+
+// update 清除上次输出的内容并输出 content
+func (a *area) update(content string) {
+	if a.writer == nil {
+		a.writer = os.Stdout
+	}
+	var buffer strings.Builder
+	buffer.WriteString("\r\x1b[2K")
+	for range a.height {
+		buffer.WriteString("\x1b[1A\x1b[2K")
+	}
+	buffer.WriteString(content)
+	_, _ = fmt.Fprint(a.writer, buffer.String())
+	a.height = strings.Count(content, "\n")
+}
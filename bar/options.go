@@ -0,0 +1,108 @@
+package bar
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// Theme 终端模式下的样式
+type Theme struct {
+	Spinner pterm.SpinnerPrinter // bar 使用的 spinner，Writer 会被替换为 Bar 的 writer
+	Table   pterm.TablePrinter   // Stop 后输出汇总使用的表格，Writer 会被替换为 Bar 的 writer
+}
+
+// DefaultTheme 默认样式
+var DefaultTheme = Theme{
+	Spinner: pterm.DefaultSpinner,
+	Table:   *pterm.DefaultTable.WithHasHeader(),
+}
+
+// options New 的可选配置
+type options struct {
+	writer      io.Writer
+	updateDelay time.Duration
+	theme       Theme
+	mode        Mode
+	reportFile  string
+}
+
+// Option New 的可选配置
+type Option func(*options)
+
+// WithWriter
+//
+//	@Description: 设置输出，默认为 os.Stdout；ModeAuto 下只有 writer 为终端时才使用 spinner
+//	@param writer
+//	@return Option
+func WithWriter(writer io.Writer) Option {
+	return func(o *options) {
+		o.writer = writer
+	}
+}
+
+// WithUpdateDelay
+//
+//	@Description: 设置终端模式下的刷新间隔，默认为 200ms
+//	@param delay
+//	@return Option
+func WithUpdateDelay(delay time.Duration) Option {
+	return func(o *options) {
+		o.updateDelay = delay
+	}
+}
+
+// WithTheme
+//
+//	@Description: 设置终端模式下的样式，默认为 DefaultTheme
+//	@param theme
+//	@return Option
+func WithTheme(theme Theme) Option {
+	return func(o *options) {
+		o.theme = theme
+	}
+}
+
+// WithMode
+//
+//	@Description: 设置输出模式，默认为 ModeAuto
+//	@param mode
+//	@return Option
+func WithMode(mode Mode) Option {
+	return func(o *options) {
+		o.mode = mode
+	}
+}
+
+// WithReportFile
+//
+//	@Description: Stop 时将报告写入 file，.xml 后缀为 JUnit 格式，其他为 JSON 格式
+//	@param file
+//	@return Option
+func WithReportFile(file string) Option {
+	return func(o *options) {
+		o.reportFile = file
+	}
+}
+
+// newOptions 应用 opts 并补全默认值
+func newOptions(opts ...Option) *options {
+	o := &options{
+		writer:      os.Stdout,
+		updateDelay: defaultUpdateDelay,
+		theme:       DefaultTheme,
+		mode:        ModeAuto,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.writer == nil {
+		o.writer = os.Stdout
+	}
+	if o.updateDelay <= 0 {
+		o.updateDelay = defaultUpdateDelay
+	}
+	return o
+}
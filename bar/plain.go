@@ -0,0 +1,172 @@
+package bar
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const plainTimeFormat = "2006-01-02 15:04:05"
+
+// plainLine 纯文本模式下的一行输出，json 模式下按此结构编码
+type plainLine struct {
+	Time     time.Time `json:"time"`
+	Bar      string    `json:"bar"`
+	Name     string    `json:"name,omitempty"`
+	Parent   string    `json:"parent,omitempty"`
+	Event    string    `json:"event"`
+	Msg      string    `json:"msg"`
+	State    string    `json:"state,omitempty"`    // 仅 summary
+	Duration float64   `json:"duration,omitempty"` // 仅 summary，单位秒
+}
+
+// plainRenderer 不刷新终端，每次状态变化输出一行日志，适用于 systemd、CI 或重定向到文件
+type plainRenderer struct {
+	name   string
+	writer io.Writer
+	json   bool
+	mux    sync.Mutex
+	bars   map[string]bool
+	labels map[string]string
+}
+
+// newPlainRenderer
+//
+//	@Description: 创建纯文本 renderer，并开始名称为 name 的根 bar
+//	@param name
+//	@param writer
+//	@param jsonLine 是否输出 json
+//	@return *plainRenderer
+func newPlainRenderer(name string, writer io.Writer, jsonLine bool) *plainRenderer {
+	r := &plainRenderer{
+		name:   name,
+		writer: writer,
+		json:   jsonLine,
+		bars:   make(map[string]bool),
+		labels: make(map[string]string),
+	}
+	r.start(name, "", name+"...", 0)
+	return r
+}
+
+func (r *plainRenderer) start(barName, parentName, msg string, _ int) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.bars[barName] = true
+	// 子 bar 显示为 父/子
+	r.labels[barName] = barName
+	if parent, ok := r.labels[parentName]; ok && parentName != "" {
+		r.labels[barName] = parent + "/" + barName
+	}
+	r.write(barName, "start", msg)
+}
+
+func (r *plainRenderer) update(barName, msg string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.bars[barName] {
+		r.write(barName, "update", msg)
+	}
+}
+
+func (r *plainRenderer) setState(barName, msg string, state State) bool {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if _, ok := r.bars[barName]; !ok {
+		return false
+	}
+	r.bars[barName] = false
+	r.write(barName, strings.ToLower(state.String()), msg)
+	return true
+}
+
+func (r *plainRenderer) stopBar(barName string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.bars[barName] {
+		r.bars[barName] = false
+		r.write(barName, "stop", "")
+	}
+}
+
+func (r *plainRenderer) print(msg string, state State) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.write("", strings.ToLower(state.String()), msg)
+}
+
+// collapse 日志无法折叠
+func (r *plainRenderer) collapse([]string) {}
+
+func (r *plainRenderer) stop() {}
+
+// summary 每个 step 输出一行
+func (r *plainRenderer) summary(steps []step) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	for _, s := range steps {
+		d := s.duration()
+		line := r.newLine(s.name, "summary", s.lastMsg())
+		line.State = s.stateString()
+		line.Duration = d.Seconds()
+		if r.json {
+			r.writeJSON(line)
+			continue
+		}
+		_, _ = fmt.Fprintf(r.writer, "%s [SUMMARY] %s: %s %s %s\n",
+			line.Time.Format(plainTimeFormat), r.label(s.name), line.State, d.Round(time.Millisecond), line.Msg)
+	}
+}
+
+// progressInterval 纯文本日志不需要频繁输出进度
+func (r *plainRenderer) progressInterval() time.Duration {
+	return 5 * time.Second
+}
+
+// write
+//
+//	@Description: 输出一行，调用方需持有锁
+//	@receiver r
+//	@param barName 为空或与根 bar 同名时只显示根 bar 名称
+//	@param event
+//	@param msg
+func (r *plainRenderer) write(barName, event, msg string) {
+	line := r.newLine(barName, event, msg)
+	if r.json {
+		r.writeJSON(line)
+		return
+	}
+	_, _ = fmt.Fprintf(r.writer, "%s [%s] %s: %s\n", line.Time.Format(plainTimeFormat), strings.ToUpper(event), r.label(barName), msg)
+}
+
+func (r *plainRenderer) newLine(barName, event, msg string) plainLine {
+	if barName == r.name {
+		barName = ""
+	}
+	line := plainLine{Time: time.Now(), Bar: r.name, Name: barName, Event: event, Msg: msg}
+	if label, ok := r.labels[barName]; ok {
+		if i := strings.LastIndex(label, "/"); i >= 0 {
+			line.Parent = label[:i]
+		}
+	}
+	return line
+}
+
+func (r *plainRenderer) writeJSON(line plainLine) {
+	data, _ := json.Marshal(line)
+	_, _ = fmt.Fprintf(r.writer, "%s\n", data)
+}
+
+// label 根 bar 名称/父 bar/子 bar
+func (r *plainRenderer) label(barName string) string {
+	if barName == "" || barName == r.name {
+		return r.name
+	}
+	if label, ok := r.labels[barName]; ok {
+		return r.name + "/" + label
+	}
+	return r.name + "/" + barName
+}
@@ -0,0 +1,226 @@
+package bar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const progressWidth = 30
+
+// Progress 确定进度的 bar，展示 当前量/总量、速率与剩余时间，单位为字节
+type Progress struct {
+	bar        *Bar
+	name       string
+	title      string
+	total      atomic.Int64
+	current    atomic.Int64
+	start      time.Time
+	lastRender atomic.Int64
+}
+
+// AddProgressBar
+//
+//	@Description: 添加一个确定进度的 bar
+//	@receiver b
+//	@param barName
+//	@param title 显示在进度前的标题
+//	@param total 总字节数，未知时为 0，可通过 SetTotal 设置
+//	@return *Progress
+func (b *Bar) AddProgressBar(barName, title string, total int64) *Progress {
+	return b.AddSubProgressBar("", barName, title, total)
+}
+
+// AddSubProgressBar
+//
+//	@Description: 在 parentName 下添加一个确定进度的子 bar
+//	@receiver b
+//	@param parentName 为空或不存在时等同于 AddProgressBar
+//	@param barName
+//	@param title
+//	@param total
+//	@return *Progress
+func (b *Bar) AddSubProgressBar(parentName, barName, title string, total int64) *Progress {
+	p := &Progress{
+		bar:   b,
+		name:  barName,
+		title: title,
+		start: time.Now(),
+	}
+	p.total.Store(total)
+	b.AddStartSubBar(parentName, barName, p.String())
+	return p
+}
+
+// SetTotal 设置总字节数
+func (p *Progress) SetTotal(total int64) {
+	p.total.Store(total)
+	p.render(true)
+}
+
+// Add 增加已完成的字节数
+func (p *Progress) Add(n int64) {
+	p.current.Add(n)
+	p.render(false)
+}
+
+// Set 设置已完成的字节数
+func (p *Progress) Set(current int64) {
+	p.current.Store(current)
+	p.render(false)
+}
+
+// Current 已完成的字节数
+func (p *Progress) Current() int64 {
+	return p.current.Load()
+}
+
+// Total 总字节数
+func (p *Progress) Total() int64 {
+	return p.total.Load()
+}
+
+// Rate
+//
+//	@Description: 从开始到现在的平均速率
+//	@receiver p
+//	@return float64 字节/秒
+func (p *Progress) Rate() float64 {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Current()) / elapsed
+}
+
+// ETA
+//
+//	@Description: 按平均速率估算的剩余时间，总量未知或速率为 0 时返回 0
+//	@receiver p
+//	@return time.Duration
+func (p *Progress) ETA() time.Duration {
+	rate := p.Rate()
+	remaining := p.Total() - p.Current()
+	if rate <= 0 || p.Total() <= 0 || remaining <= 0 {
+		return 0
+	}
+	return time.Duration(float64(remaining) / rate * float64(time.Second))
+}
+
+// String
+//
+//	@Description: 进度文本，例如 "加载镜像 [=======>      ]  45.0% 120.0MiB/300.0MiB 12.3MiB/s ETA 15s"
+//	@receiver p
+//	@return string
+func (p *Progress) String() string {
+	current, total := p.Current(), p.Total()
+	rate := fmt.Sprintf("%s/s", FormatBytes(int64(p.Rate())))
+	if total <= 0 {
+		return fmt.Sprintf("%s %s %s", p.title, FormatBytes(current), rate)
+	}
+	percent := float64(current) / float64(total)
+	if percent > 1 {
+		percent = 1
+	}
+	filled := int(percent * progressWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressWidth {
+		bar += ">" + strings.Repeat(" ", progressWidth-filled-1)
+	}
+	return fmt.Sprintf("%s [%s] %5.1f%% %s/%s %s ETA %s",
+		p.title, bar, percent*100, FormatBytes(current), FormatBytes(total), rate, p.ETA().Round(time.Second))
+}
+
+// Done
+//
+//	@Description: 结束进度并设置状态
+//	@receiver p
+//	@param msg 为空时显示标题、总量与耗时
+//	@param state
+func (p *Progress) Done(msg string, state State) {
+	if msg == "" {
+		msg = fmt.Sprintf("%s %s 耗时 %s", p.title, FormatBytes(p.Current()), time.Since(p.start).Round(time.Millisecond))
+	}
+	p.bar.SetBarState(p.name, msg, state)
+}
+
+// Reader
+//
+//	@Description: 包装 io.Reader，读取时自动更新进度
+//	@receiver p
+//	@param r
+//	@return io.Reader
+func (p *Progress) Reader(r io.Reader) io.Reader {
+	return &progressReader{reader: r, progress: p}
+}
+
+// Writer
+//
+//	@Description: 包装 io.Writer，写入时自动更新进度
+//	@receiver p
+//	@param w
+//	@return io.Writer
+func (p *Progress) Writer(w io.Writer) io.Writer {
+	return &progressWriter{writer: w, progress: p}
+}
+
+// render
+//
+//	@Description: 按 renderer 的刷新间隔更新 bar 的 msg，避免频繁刷新
+//	@receiver p
+//	@param force 忽略刷新间隔
+func (p *Progress) render(force bool) {
+	now := time.Now().UnixNano()
+	last := p.lastRender.Load()
+	if !force && now-last < int64(p.bar.renderer.progressInterval()) {
+		return
+	}
+	if !p.lastRender.CompareAndSwap(last, now) {
+		return
+	}
+	p.bar.UpdateStartBarMsg(p.name, p.String())
+}
+
+type progressReader struct {
+	reader   io.Reader
+	progress *Progress
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.progress.Add(int64(n))
+	//nolint:wrapcheck
+	return n, err
+}
+
+type progressWriter struct {
+	writer   io.Writer
+	progress *Progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.progress.Add(int64(n))
+	//nolint:wrapcheck
+	return n, err
+}
+
+// FormatBytes
+//
+//	@Description: 格式化字节数，例如 1.5MiB
+//	@param n
+//	@return string
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
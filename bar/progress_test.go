@@ -0,0 +1,58 @@
+package bar
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	var buf bytes.Buffer
+	b := &Bar{name: "加载镜像", steps: make(map[string]*step), renderer: newPlainRenderer("加载镜像", &buf, false)}
+	p := b.AddProgressBar("image", "nginx.tar", 4096)
+
+	n, err := io.Copy(io.Discard, p.Reader(strings.NewReader(strings.Repeat("a", 1024))))
+	if err != nil || n != 1024 {
+		t.Fatalf("io.Copy() = %d, %v", n, err)
+	}
+	if p.Current() != 1024 || p.Total() != 4096 {
+		t.Errorf("progress = %d/%d", p.Current(), p.Total())
+	}
+	if s := p.String(); !strings.Contains(s, " 25.0% 1.0KiB/4.0KiB ") || !strings.Contains(s, "[=======>") {
+		t.Errorf("String() = %s", s)
+	}
+
+	p.start = time.Now().Add(-time.Second)
+	if eta := p.ETA(); eta < 2900*time.Millisecond || eta > 3100*time.Millisecond {
+		t.Errorf("ETA() = %s", eta)
+	}
+
+	_, err = p.Writer(io.Discard).Write(make([]byte, 3072))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Current() != 4096 || p.ETA() != 0 {
+		t.Errorf("progress = %d/%d, ETA = %s", p.Current(), p.Total(), p.ETA())
+	}
+	p.Done("", StateSuccess)
+	if !strings.Contains(buf.String(), "[SUCCESS] 加载镜像/image: nginx.tar 4.0KiB 耗时") {
+		t.Errorf("output = %s", buf.String())
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:                "0B",
+		1023:             "1023B",
+		1536:             "1.5KiB",
+		10 * 1024 * 1024: "10.0MiB",
+		3 << 30:          "3.0GiB",
+	}
+	for in, want := range tests {
+		if got := FormatBytes(in); got != want {
+			t.Errorf("FormatBytes(%d) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package bar
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+type Mode int
+
+const (
+	ModeAuto     Mode = iota // 终端下使用 spinner，否则输出纯文本日志
+	ModeTerminal             // pterm spinner
+	ModePlain                // 每个状态变化输出一行带时间的纯文本日志
+	ModeJSON                 // 每个状态变化输出一行 json
+)
+
+// renderer 负责 bar 的输出，终端下为 spinner，systemd、CI 或重定向到文件时为纯文本日志
+type renderer interface {
+	// start 开始一个 bar，同名的 bar 会重新开始，depth 为在分组中的层级
+	start(barName, parentName, msg string, depth int)
+	// update 更新 bar 的 msg
+	update(barName, msg string)
+	// setState 设置 bar 的最终状态，bar 不存在时返回 false
+	setState(barName, msg string, state State) bool
+	// stopBar 停止 bar，不设置状态
+	stopBar(barName string)
+	// print 输出一行与 bar 无关的信息
+	print(msg string, state State)
+	// collapse 折叠已结束分组的子 bar
+	collapse(barNames []string)
+	// stop 停止所有输出
+	stop()
+	// summary 在 stop 之后输出所有 step 的汇总
+	summary(steps []step)
+	// progressInterval 确定进度的 bar 最小刷新间隔
+	progressInterval() time.Duration
+}
+
+// newRenderer
+//
+//	@Description: 根据模式创建 renderer
+//	@param name
+//	@param o
+//	@return renderer
+func newRenderer(name string, o *options) renderer {
+	mode := o.mode
+	if mode == ModeAuto {
+		mode = ModePlain
+		if isTerminal(o.writer) {
+			mode = ModeTerminal
+		}
+	}
+	switch mode {
+	case ModePlain:
+		return newPlainRenderer(name, o.writer, false)
+	case ModeJSON:
+		return newPlainRenderer(name, o.writer, true)
+	default:
+		return newTerminalRenderer(name, o)
+	}
+}
+
+func isTerminal(writer io.Writer) bool {
+	f, ok := writer.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd())) //nolint:gosec
+}
@@ -0,0 +1,221 @@
+package bar
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// StepReport 一个 bar 的执行结果
+type StepReport struct {
+	Name      string    `json:"name"`
+	Parent    string    `json:"parent,omitempty"`
+	Msgs      []string  `json:"msgs"`
+	State     State     `json:"state,omitempty"`     // 为空表示未结束
+	StateName string    `json:"stateName,omitempty"` // State 的名称，例如 Success
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end,omitzero"`
+	Duration  float64   `json:"duration"` // 单位秒，未结束的为到生成报告时的耗时
+}
+
+// Report 整个 Bar 的执行报告
+type Report struct {
+	StepReport
+	Steps []StepReport `json:"steps"`
+}
+
+// newStepReport 由 step 生成报告
+func newStepReport(s *step) StepReport {
+	report := StepReport{
+		Name:     s.name,
+		Parent:   s.parent,
+		Msgs:     append([]string{}, s.msgs...),
+		State:    s.state,
+		Start:    s.start,
+		End:      s.end,
+		Duration: s.duration().Seconds(),
+	}
+	if s.state != 0 {
+		report.StateName = s.state.String()
+	}
+	return report
+}
+
+// Report
+//
+//	@Description: 生成所有 bar 的执行报告，Stop 之前调用时未结束的 bar 没有 State
+//	@receiver b
+//	@return *Report
+func (b *Bar) Report() *Report {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.newReport()
+}
+
+// newReport 调用方需持有锁
+func (b *Bar) newReport() *Report {
+	report := &Report{StepReport: newStepReport(&b.root)}
+	report.Name = b.name
+	for _, s := range b.sortedSteps() {
+		report.Steps = append(report.Steps, newStepReport(&s))
+	}
+	return report
+}
+
+// Failures 失败的 bar 的数量
+func (r *Report) Failures() int {
+	failures := 0
+	for _, s := range r.Steps {
+		if s.State == StateFail {
+			failures++
+		}
+	}
+	return failures
+}
+
+// WriteJSON
+//
+//	@Description: 以 JSON 格式输出报告
+//	@receiver r
+//	@param w
+//	@return error
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("报告写入失败: %w", err)
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit
+//
+//	@Description: 以 JUnit XML 格式输出报告，每个 bar 为一个 testcase，子 bar 的名称为 父/子；Fail 为 failure，未结束的为 skipped
+//	@receiver r
+//	@param w
+//	@return error
+func (r *Report) WriteJUnit(w io.Writer) error {
+	seconds := func(d float64) string {
+		return fmt.Sprintf("%.3f", d)
+	}
+	suite := junitTestSuite{
+		Name:      r.Name,
+		Tests:     len(r.Steps),
+		Failures:  r.Failures(),
+		Time:      seconds(r.Duration),
+		Timestamp: r.Start.Format(time.RFC3339),
+	}
+	paths := make(map[string]string, len(r.Steps))
+	for _, s := range r.Steps {
+		path := s.Name
+		if parent, ok := paths[s.Parent]; ok {
+			path = parent + "/" + s.Name
+		}
+		paths[s.Name] = path
+
+		testCase := junitTestCase{
+			Name:      path,
+			ClassName: r.Name,
+			Time:      seconds(s.Duration),
+			SystemOut: strings.Join(s.Msgs, "\n"),
+		}
+		lastMsg := ""
+		if len(s.Msgs) > 0 {
+			lastMsg = s.Msgs[len(s.Msgs)-1]
+		}
+		switch s.State {
+		case StateFail:
+			testCase.Failure = &junitMessage{Message: lastMsg, Text: testCase.SystemOut}
+		case 0:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: "未结束"}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	suites := junitTestSuites{
+		Name:     r.Name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("报告写入失败: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return fmt.Errorf("报告写入失败: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	if err != nil {
+		return fmt.Errorf("报告写入失败: %w", err)
+	}
+	return nil
+}
+
+// WriteFile
+//
+//	@Description: 将报告写入文件，.xml 后缀为 JUnit 格式，其他为 JSON 格式
+//	@receiver r
+//	@param file
+//	@return error
+func (r *Report) WriteFile(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return fmt.Errorf("报告目录创建失败: %s, err: %w", file, err)
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("报告文件创建失败: %s, err: %w", file, err)
+	}
+	if strings.EqualFold(filepath.Ext(file), ".xml") {
+		err = r.WriteJUnit(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	// Close 失败时数据可能没有写入磁盘
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("报告文件关闭失败: %s, err: %w", file, closeErr)
+	}
+	return err
+}
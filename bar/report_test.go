@@ -0,0 +1,84 @@
+package bar
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newReportBar(t *testing.T, opts ...Option) *Bar {
+	t.Helper()
+	var buf bytes.Buffer
+	bar := New("部署", append([]Option{WithMode(ModePlain), WithWriter(&buf)}, opts...)...)
+	bar.AddStartBar("k8s", "部署 k8s...")
+	bar.AddStartSubBar("k8s", "etcd", "部署 etcd...")
+	bar.SetBarState("etcd", "etcd：OK", StateSuccess)
+	bar.AddStartSubBar("k8s", "apiserver", "部署 apiserver...")
+	bar.SetBarState("apiserver", "apiserver：Fail", StateFail)
+	bar.SetBarState("k8s", "k8s：OK", StateSuccess)
+	bar.AddStartBar("harbor", "部署 harbor...")
+	return bar
+}
+
+func TestReport(t *testing.T) {
+	bar := newReportBar(t)
+	bar.Stop()
+	report := bar.Report()
+
+	if report.Name != "部署" || report.State != StateWarning || report.End.IsZero() {
+		t.Errorf("report = %+v", report.StepReport)
+	}
+	if len(report.Steps) != 4 || report.Failures() != 1 {
+		t.Fatalf("steps = %+v", report.Steps)
+	}
+	etcd := report.Steps[1]
+	if etcd.Name != "etcd" || etcd.Parent != "k8s" || etcd.State != StateSuccess ||
+		strings.Join(etcd.Msgs, ",") != "部署 etcd...,etcd：OK" {
+		t.Errorf("etcd = %+v", etcd)
+	}
+	if report.Steps[3].State != 0 {
+		t.Errorf("harbor = %+v", report.Steps[3])
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.State != StateWarning || decoded.Steps[2].State != StateFail {
+		t.Errorf("decoded = %s", buf.String())
+	}
+}
+
+func TestReportJUnit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "report", "junit.xml")
+	bar := newReportBar(t, WithReportFile(file))
+	bar.Stop()
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var suites junitTestSuites
+	if err := xml.Unmarshal(data, &suites); err != nil {
+		t.Fatal(err)
+	}
+	suite := suites.Suites[0]
+	if suites.Tests != 4 || suites.Failures != 1 || suite.Skipped != 1 {
+		t.Errorf("suites = %s", data)
+	}
+	apiserver := suite.Cases[2]
+	if apiserver.Name != "k8s/apiserver" || apiserver.Failure == nil || apiserver.Failure.Message != "apiserver：Fail" {
+		t.Errorf("apiserver = %+v", apiserver)
+	}
+	if suite.Cases[3].Skipped == nil {
+		t.Errorf("harbor = %+v", suite.Cases[3])
+	}
+}
@@ -0,0 +1,148 @@
+package bar
+
+import (
+	"fmt"
+	"time"
+)
+
+// step 一个 bar 的执行记录，用于分组、耗时统计和最终汇总
+type step struct {
+	name     string
+	parent   string
+	depth    int
+	msgs     []string // 开始与结束时的 msg
+	state    State    // 0 表示未结束
+	start    time.Time
+	end      time.Time
+	children []string
+}
+
+// duration 已结束的返回总耗时，未结束的返回到现在的耗时
+func (s *step) duration() time.Duration {
+	if s.end.IsZero() {
+		return time.Since(s.start)
+	}
+	return s.end.Sub(s.start)
+}
+
+// stateString 未结束的 step 显示为 Running
+func (s *step) stateString() string {
+	if s.state == 0 {
+		return "Running"
+	}
+	return s.state.String()
+}
+
+// lastMsg 最后一条 msg
+func (s *step) lastMsg() string {
+	if len(s.msgs) == 0 {
+		return ""
+	}
+	return s.msgs[len(s.msgs)-1]
+}
+
+// startStep
+//
+//	@Description: 记录 step 开始，同名 step 会重新开始，调用方需持有锁
+//	@receiver b
+//	@param parentName 为空或不存在时为顶层 step
+//	@param barName
+//	@param msg
+//	@return *step
+func (b *Bar) startStep(parentName, barName, msg string) *step {
+	depth := 0
+	parent, ok := b.steps[parentName]
+	if !ok {
+		parentName = ""
+	}
+	s, exist := b.steps[barName]
+	if !exist {
+		s = &step{name: barName}
+		b.steps[barName] = s
+		if parent != nil {
+			parent.children = append(parent.children, barName)
+		} else {
+			b.roots = append(b.roots, barName)
+		}
+	}
+	if parent != nil {
+		depth = parent.depth + 1
+	}
+	s.parent = parentName
+	s.depth = depth
+	s.msgs = append(s.msgs, msg)
+	s.state = 0
+	s.start = time.Now()
+	s.end = time.Time{}
+	return s
+}
+
+// parentOf 父 bar 名称，调用方需持有锁
+func (b *Bar) parentOf(barName string) string {
+	if s, ok := b.steps[barName]; ok {
+		return s.parent
+	}
+	return ""
+}
+
+// finished
+//
+//	@Description: step 及其所有子 step 都已结束，调用方需持有锁
+//	@receiver b
+//	@param s
+//	@return bool
+func (b *Bar) finished(s *step) bool {
+	if s.state == 0 {
+		return false
+	}
+	for _, child := range s.children {
+		if c, ok := b.steps[child]; ok && !b.finished(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// descendants
+//
+//	@Description: 所有子孙 step 的名称，调用方需持有锁
+//	@receiver b
+//	@param s
+//	@return []string
+func (b *Bar) descendants(s *step) []string {
+	var names []string
+	for _, child := range s.children {
+		names = append(names, child)
+		if c, ok := b.steps[child]; ok {
+			names = append(names, b.descendants(c)...)
+		}
+	}
+	return names
+}
+
+// sortedSteps
+//
+//	@Description: 按树的先序遍历返回所有 step 的副本，调用方需持有锁
+//	@receiver b
+//	@return []step
+func (b *Bar) sortedSteps() []step {
+	steps := make([]step, 0, len(b.steps))
+	var walk func(names []string)
+	walk = func(names []string) {
+		for _, name := range names {
+			s, ok := b.steps[name]
+			if !ok {
+				continue
+			}
+			steps = append(steps, *s)
+			walk(s.children)
+		}
+	}
+	walk(b.roots)
+	return steps
+}
+
+// elapsedMsg 分组结束时在 msg 后追加耗时
+func elapsedMsg(msg string, d time.Duration) string {
+	return fmt.Sprintf("%s (耗时 %s)", msg, d.Round(time.Millisecond))
+}
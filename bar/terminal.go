@@ -0,0 +1,153 @@
+package bar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// terminalRenderer 使用 pterm spinner 在终端中刷新输出
+type terminalRenderer struct {
+	multiBar *MultiPrinter
+	theme    Theme
+	barMap   sync.Map
+}
+
+// newTerminalRenderer
+//
+//	@Description: 创建终端 renderer，并开始名称为 name 的根 bar
+//	@param name
+//	@param o
+//	@return *terminalRenderer
+func newTerminalRenderer(name string, o *options) *terminalRenderer {
+	r := &terminalRenderer{
+		// 每个 Bar 使用独立的 MultiPrinter，不复制 DefaultMultiPrinter 中的 writer
+		multiBar: &MultiPrinter{
+			Writer:      &syncWriter{writer: o.writer},
+			UpdateDelay: o.updateDelay,
+			mux:         &sync.Mutex{},
+		},
+		theme:  o.theme,
+		barMap: sync.Map{},
+	}
+	r.print(name, StateInfo)
+	start, _ := r.theme.Spinner.WithWriter(r.multiBar.Writer).Start(fmt.Sprintf("%s...", name))
+
+	_, _ = r.multiBar.Start()
+	r.barMap.Store(name, start)
+	return r
+}
+
+func (r *terminalRenderer) start(barName, _, msg string, depth int) {
+	if br := r.mapLoad(barName); br != nil {
+		bar, _ := br.WithWriter(r.multiBar.NewWriterWithIndent(depth)).Start(msg)
+		r.barMap.Store(barName, bar)
+		return
+	}
+	bar, _ := r.theme.Spinner.WithWriter(r.multiBar.NewWriterWithIndent(depth)).Start(msg)
+
+	r.barMap.Store(barName, bar)
+}
+
+func (r *terminalRenderer) update(barName, msg string) {
+	if r.mapLoad(barName) != nil {
+		r.mapLoad(barName).UpdateText(msg)
+	}
+}
+
+func (r *terminalRenderer) setState(barName, msg string, state State) bool {
+	bar := r.mapLoad(barName)
+	if bar == nil {
+		return false
+	}
+	switch state {
+	case StateInfo:
+		bar.Info(msg)
+	case StateWarning:
+		bar.Warning(msg)
+	case StateFail:
+		bar.Fail(msg)
+	case StateSuccess:
+		bar.Success(msg)
+	}
+	_ = bar.Stop()
+	return true
+}
+
+func (r *terminalRenderer) stopBar(barName string) {
+	if bar := r.mapLoad(barName); bar != nil {
+		_ = bar.Stop()
+	}
+}
+
+func (r *terminalRenderer) print(msg string, state State) {
+	spinner := r.theme.Spinner.WithWriter(r.multiBar.NewWriter())
+	switch state {
+	case StateWarning:
+		spinner.Warning(msg)
+	case StateFail:
+		spinner.Fail(msg)
+	case StateSuccess:
+		spinner.Success(msg)
+	default:
+		spinner.Info(msg)
+	}
+}
+
+func (r *terminalRenderer) collapse(barNames []string) {
+	for _, barName := range barNames {
+		if bar := r.mapLoad(barName); bar != nil {
+			r.multiBar.RemoveWriter(bar.Writer)
+		}
+	}
+}
+
+func (r *terminalRenderer) stop() {
+	_, _ = r.multiBar.Stop()
+}
+
+// summary 以表格输出所有 step
+func (r *terminalRenderer) summary(steps []step) {
+	if len(steps) == 0 {
+		return
+	}
+	data := pterm.TableData{{"步骤", "状态", "耗时", "信息"}}
+	for _, s := range steps {
+		data = append(data, []string{
+			strings.Repeat("  ", s.depth) + s.name,
+			s.stateString(),
+			s.duration().Round(time.Millisecond).String(),
+			s.lastMsg(),
+		})
+	}
+	_ = r.theme.Table.WithWriter(r.multiBar.Writer).WithData(data).Render()
+}
+
+func (r *terminalRenderer) progressInterval() time.Duration {
+	return r.multiBar.UpdateDelay
+}
+
+//nolint:forcetypeassert
+func (r *terminalRenderer) mapLoad(name string) *pterm.SpinnerPrinter {
+	value, ok := r.barMap.Load(name)
+	if !ok {
+		return nil
+	}
+	return value.(*pterm.SpinnerPrinter)
+}
+
+// syncWriter 根 bar 的 spinner 与刷新在不同的 goroutine 中写入 writer，需要加锁
+type syncWriter struct {
+	mux    sync.Mutex
+	writer io.Writer
+}
+
+func (w *syncWriter) Write(data []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+	return w.writer.Write(data) //nolint:wrapcheck
+}
@@ -36,24 +36,15 @@ func Bash(ctx context.Context, cmd string) (string, int) {
 	return string(output), 0
 }
 
+// BashFormat
+//
+//	@Description: 格式化后执行bash命令，参数来自外部输入时需使用 Quote 转义，或改用 NewArgvCommand
+//	@param format
+//	@param a
+//	@return out
+//	@return exitCode
 func BashFormat(ctx context.Context, format string, a ...any) (string, int) {
-	cmd := fmt.Sprintf(format, a...)
-	if cmd == "" {
-		return "", 0
-	}
-	command := exec.CommandContext(ctx, "bash", "-c", cmd)
-	command.Env = append(command.Environ(), "LANG=en_US.utf8", "LANGUAGE=en_US.utf8")
-	output, err := command.CombinedOutput()
-	if err != nil {
-		//nolint:errorlint
-		if ins, ok := err.(*exec.ExitError); ok {
-			out := string(output)
-			exitCode := ins.ExitCode()
-			return out, exitCode
-		}
-		return err.Error(), 1
-	}
-	return string(output), 0
+	return Bash(ctx, fmt.Sprintf(format, a...))
 }
 
 // BashWithWorkDir
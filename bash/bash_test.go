@@ -0,0 +1,135 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommand_Run(t *testing.T) {
+	result := NewCommand("echo out; echo err >&2; exit 3").Run(context.Background())
+	if result.Stdout != "out\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+	if result.Stderr != "err\n" {
+		t.Errorf("Stderr = %q", result.Stderr)
+	}
+	if result.ExitCode != 3 || result.Err == nil || result.Success() {
+		t.Errorf("ExitCode = %d, Err = %v", result.ExitCode, result.Err)
+	}
+}
+
+func TestCommand_WithEnvStdinDir(t *testing.T) {
+	dir := t.TempDir()
+	result := NewCommand(`echo "$FOO $LANG $(pwd)"; cat`).
+		WithEnv("FOO=bar", "LANG=C").
+		WithStdin(strings.NewReader("input")).
+		WithDir(dir).
+		Run(context.Background())
+	if !result.Success() {
+		t.Fatalf("Run() err = %v, stderr = %s", result.Err, result.Stderr)
+	}
+	want := "bar C " + dir + "\ninput"
+	if result.Stdout != want {
+		t.Errorf("Stdout = %q, want %q", result.Stdout, want)
+	}
+}
+
+func TestCommand_WithTimeout(t *testing.T) {
+	result := NewCommand("sleep 5").WithTimeout(100 * time.Millisecond).Run(context.Background())
+	if !errors.Is(result.Err, ErrBashTimeout) {
+		t.Errorf("Err = %v, want ErrBashTimeout", result.Err)
+	}
+	if result.Duration >= 5*time.Second {
+		t.Errorf("Duration = %s", result.Duration)
+	}
+
+	// 复合命令中的子进程也持有 stdout，需要终止整个进程组
+	result = NewCommand("sleep 3; echo hi").WithTimeout(200 * time.Millisecond).Run(context.Background())
+	if !errors.Is(result.Err, ErrBashTimeout) {
+		t.Errorf("Err = %v, want ErrBashTimeout", result.Err)
+	}
+	if result.Duration >= time.Second {
+		t.Errorf("compound Duration = %s", result.Duration)
+	}
+}
+
+func TestBashStream(t *testing.T) {
+	var lines []Line
+	result := BashStream(context.Background(), "echo a; echo b >&2; printf c", func(line Line) {
+		lines = append(lines, line)
+	})
+	if !result.Success() {
+		t.Fatalf("BashStream() err = %v", result.Err)
+	}
+	var stdout, stderr []string
+	for _, line := range lines {
+		switch line.Stream {
+		case StreamStdout:
+			stdout = append(stdout, line.Text)
+		case StreamStderr:
+			stderr = append(stderr, line.Text)
+		}
+	}
+	if strings.Join(stdout, ",") != "a,c" || strings.Join(stderr, ",") != "b" {
+		t.Errorf("lines = %v", lines)
+	}
+	if result.Stdout != "a\nc" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+}
+
+func TestBashStream_SlowConsumer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	// 没有消费者的 channel 不能阻塞命令，超时后 Run 返回
+	ch := make(chan string)
+	start := time.Now()
+	result := NewCommand("for i in $(seq 1000); do echo $i; done; sleep 5").
+		WithLineFunc(LineChan(ctx, ch)).
+		Run(ctx)
+	if result.Err == nil {
+		t.Error("Run() should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run() took %s", elapsed)
+	}
+	if !strings.HasPrefix(result.Stdout, "1\n2\n") {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"":            "''",
+		"ens192":      "ens192",
+		"8.8.8.8,1.1": "8.8.8.8,1.1",
+		"a b":         "'a b'",
+		"it's":        `'it'\''s'`,
+		"$(reboot)":   "'$(reboot)'",
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+	}
+
+	args := []string{"a b", "it's", "; rm -rf /", "$HOME"}
+	result := NewCommand("printf '%s\\n' " + QuoteArgs(args...)).Run(context.Background())
+	if result.Stdout != strings.Join(args, "\n")+"\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+}
+
+func TestNewArgvCommand(t *testing.T) {
+	c := NewArgvCommand("echo", "a b", "$HOME")
+	if c.String() != "echo 'a b' '$HOME'" {
+		t.Errorf("String() = %s", c.String())
+	}
+	result := c.Run(context.Background())
+	if result.Stdout != "a b $HOME\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+}
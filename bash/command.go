@@ -0,0 +1,237 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrBashTimeout = errors.New("bash执行超时")
+
+// waitDelay 命令被终止后，等待输出读取完成的最长时间
+const waitDelay = time.Second
+
+// defaultEnv 默认的语言环境，保证命令输出为英文，便于解析
+var defaultEnv = []string{"LANG=en_US.utf8", "LANGUAGE=en_US.utf8"}
+
+// Result 命令的执行结果
+type Result struct {
+	Stdout   string        // 标准输出
+	Stderr   string        // 标准错误
+	ExitCode int           // 退出码
+	Duration time.Duration // 执行耗时
+	Err      error         // 底层错误，退出码非0时为 *exec.ExitError 或 *ssh.ExitError
+}
+
+// Success
+//
+//	@Description: 命令是否执行成功
+//	@receiver r
+//	@return bool
+func (r *Result) Success() bool {
+	return r.Err == nil && r.ExitCode == 0
+}
+
+// Command 命令构建器，通过 With 系列方法设置环境变量、标准输入、工作目录、超时时间
+// 在本机执行时，设置了超时或 ctx 可取消时命令在独立的进程组中运行，超时或取消时终止整个进程组；
+// 此时终端的 Ctrl-C 不会发送给子进程，调用方需在收到信号时取消 ctx，例如使用 signal.NotifyContext
+type Command struct {
+	cmd      string
+	argv     []string
+	env      []string
+	stdin    io.Reader
+	dir      string
+	timeout  time.Duration
+	lineFunc LineFunc
+}
+
+// NewCommand
+//
+//	@Description: 创建一个通过 bash -c 执行的命令
+//	@param cmd
+//	@return *Command
+func NewCommand(cmd string) *Command {
+	return &Command{cmd: cmd}
+}
+
+// NewArgvCommand
+//
+//	@Description: 创建一个不经过 shell 直接执行的命令，参数原样传递，无需转义
+//	@param name
+//	@param args
+//	@return *Command
+func NewArgvCommand(name string, args ...string) *Command {
+	return &Command{argv: append([]string{name}, args...)}
+}
+
+// WithEnv
+//
+//	@Description: 追加环境变量，格式为 KEY=VALUE，同名变量会覆盖默认的 LANG 设置
+//	@receiver c
+//	@param env
+//	@return *Command
+func (c *Command) WithEnv(env ...string) *Command {
+	c.env = append(c.env, env...)
+	return c
+}
+
+// WithStdin
+//
+//	@Description: 设置标准输入
+//	@receiver c
+//	@param stdin
+//	@return *Command
+func (c *Command) WithStdin(stdin io.Reader) *Command {
+	c.stdin = stdin
+	return c
+}
+
+// WithDir
+//
+//	@Description: 设置工作目录，目录不存在时会自动创建
+//	@receiver c
+//	@param dir
+//	@return *Command
+func (c *Command) WithDir(dir string) *Command {
+	c.dir = dir
+	return c
+}
+
+// WithTimeout
+//
+//	@Description: 设置单条命令的超时时间
+//	@receiver c
+//	@param timeout
+//	@return *Command
+func (c *Command) WithTimeout(timeout time.Duration) *Command {
+	c.timeout = timeout
+	return c
+}
+
+// WithLineFunc
+//
+//	@Description: 设置逐行输出回调，适用于长时间运行的命令实时展示进度
+//	@receiver c
+//	@param fn
+//	@return *Command
+func (c *Command) WithLineFunc(fn LineFunc) *Command {
+	c.lineFunc = fn
+	return c
+}
+
+// String 返回命令字符串，argv 命令会转义为等价的 shell 命令
+func (c *Command) String() string {
+	if c.argv != nil {
+		return QuoteArgs(c.argv...)
+	}
+	return c.cmd
+}
+
+// Run
+//
+//	@Description: 在本机执行命令
+//	@receiver c
+//	@param ctx
+//	@return *Result
+func (c *Command) Run(ctx context.Context) *Result {
+	start := time.Now()
+	result := &Result{}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	if c.dir != "" {
+		err := os.MkdirAll(c.dir, 0o755)
+		if err != nil {
+			result.ExitCode = 1
+			result.Err = fmt.Errorf("工作目录创建失败:%s, err: %w", c.dir, err)
+			return result
+		}
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	command := exec.CommandContext(ctx, "bash", "-c", c.cmd)
+	if c.argv != nil {
+		command = exec.CommandContext(ctx, c.argv[0], c.argv[1:]...)
+	}
+	// 只有 ctx 可能结束时才需要终止整个进程组，否则保持在当前进程组，Ctrl-C 可以同时终止子进程
+	if ctx.Done() != nil {
+		killProcessGroup(command)
+	}
+	command.WaitDelay = waitDelay
+	command.Dir = c.dir
+	command.Env = append(command.Environ(), defaultEnv...)
+	command.Env = append(command.Env, c.env...)
+	command.Stdin = c.stdin
+
+	var stdout, stderr bytes.Buffer
+	var flush func()
+	command.Stdout, command.Stderr, flush = c.writers(ctx, &stdout, &stderr)
+
+	err := command.Run()
+	flush()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.ExitCode, result.Err = exitCode(ctx, err)
+	return result
+}
+
+// writers
+//
+//	@Description: 构建 stdout、stderr 的写入器，设置了逐行回调时同时写入回调
+//	@receiver c
+//	@param ctx 结束时 flush 不再等待回调完成
+//	@param stdout
+//	@param stderr
+//	@return io.Writer
+//	@return io.Writer
+//	@return func() 命令结束后调用，输出最后一行不完整的内容，并等待所有回调完成
+func (c *Command) writers(ctx context.Context, stdout, stderr *bytes.Buffer) (io.Writer, io.Writer, func()) {
+	if c.lineFunc == nil {
+		return stdout, stderr, func() {}
+	}
+	dispatcher := newLineDispatcher(c.lineFunc)
+	stdoutLine, stderrLine := newLineWriters(dispatcher)
+	return io.MultiWriter(stdout, stdoutLine), io.MultiWriter(stderr, stderrLine), func() {
+		stdoutLine.flush()
+		stderrLine.flush()
+		dispatcher.close(ctx)
+	}
+}
+
+// exitCode
+//
+//	@Description: 从本机或 ssh 的错误中提取退出码，超时会包装为 ErrBashTimeout
+//	@param ctx
+//	@param err
+//	@return int
+//	@return error
+func exitCode(ctx context.Context, err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return -1, fmt.Errorf("%w: %w", ErrBashTimeout, err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), err
+	}
+	var sshExitErr *ssh.ExitError
+	if errors.As(err, &sshExitErr) {
+		return sshExitErr.ExitStatus(), err
+	}
+	return 1, err
+}
@@ -0,0 +1,42 @@
+package bash
+
+import (
+	"context"
+)
+
+// Executor 命令执行器，本机与远程主机实现相同的接口，便于在两者之间切换
+type Executor interface {
+	// Run 执行命令，返回分离的 stdout、stderr
+	Run(ctx context.Context, c *Command) *Result
+	// Bash 执行bash命令，返回合并的输出和退出码
+	Bash(ctx context.Context, cmd string) (string, int)
+	// BashWithWorkDir 指定工作目录执行bash命令
+	BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int)
+}
+
+var (
+	_ Executor = (*Local)(nil)
+	_ Executor = (*SSH)(nil)
+	_ Executor = (*Sudo)(nil)
+	_ Executor = (*Fake)(nil)
+	_ Executor = (*Recorder)(nil)
+)
+
+// Local 本机执行器
+type Local struct{}
+
+func NewLocal() *Local {
+	return &Local{}
+}
+
+func (l *Local) Run(ctx context.Context, c *Command) *Result {
+	return c.Run(ctx)
+}
+
+func (l *Local) Bash(ctx context.Context, cmd string) (string, int) {
+	return Bash(ctx, cmd)
+}
+
+func (l *Local) BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int) {
+	return BashWithWorkDir(ctx, cmd, dir)
+}
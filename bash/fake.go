@@ -0,0 +1,232 @@
+package bash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var ErrFixtureNotFound = errors.New("未找到匹配的命令")
+
+// Fixture 一条命令及其预设的执行结果
+type Fixture struct {
+	Command  string `json:"command,omitempty"` // 与 Command.String() 完全匹配
+	Pattern  string `json:"pattern,omitempty"` // 正则匹配，Command 为空时使用
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+}
+
+func (f *Fixture) match(cmd string) (bool, error) {
+	if f.Command != "" {
+		return f.Command == cmd, nil
+	}
+	matched, err := regexp.MatchString(f.Pattern, cmd)
+	if err != nil {
+		return false, fmt.Errorf("fixture pattern %s: %w", f.Pattern, err)
+	}
+	return matched, nil
+}
+
+// Fake 回放执行器，按 fixture 返回预设结果而不真正执行命令，用于单元测试
+type Fake struct {
+	mux      sync.Mutex
+	fixtures []Fixture
+	used     []bool // 与 fixtures 对应，是否已被回放
+	calls    []string
+}
+
+// NewFake
+//
+//	@Description: 创建回放执行器，同一条命令按顺序依次使用匹配的 fixture，用完后一直返回最后一个
+//	这样 Recorder 录制的轮询等多次执行同一命令的结果可以按原顺序回放
+//	@param fixtures
+//	@return *Fake
+func NewFake(fixtures ...Fixture) *Fake {
+	return &Fake{fixtures: fixtures, used: make([]bool, len(fixtures))}
+}
+
+// LoadFake
+//
+//	@Description: 从 Recorder.Save 保存的 json 文件创建回放执行器
+//	@param file
+//	@return *Fake
+//	@return error
+func LoadFake(file string) (*Fake, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read fixture file: %w", err)
+	}
+	var fixtures []Fixture
+	err = json.Unmarshal(data, &fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("unmarshal fixture file: %w", err)
+	}
+	return NewFake(fixtures...), nil
+}
+
+// Add 追加 fixture
+func (f *Fake) Add(fixtures ...Fixture) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.fixtures = append(f.fixtures, fixtures...)
+	f.used = append(f.used, make([]bool, len(fixtures))...)
+}
+
+// Calls 返回所有执行过的命令
+func (f *Fake) Calls() []string {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return append([]string{}, f.calls...)
+}
+
+// Run
+//
+//	@Description: 返回匹配的 fixture 结果，并按行回调输出；未匹配时 Err 为 ErrFixtureNotFound，退出码 127
+//	@receiver f
+//	@param ctx
+//	@param c
+//	@return *Result
+func (f *Fake) Run(_ context.Context, c *Command) *Result {
+	cmd := c.String()
+	fixture, ok, err := f.next(cmd)
+	if err != nil {
+		return &Result{ExitCode: 1, Err: err}
+	}
+	if !ok {
+		return &Result{ExitCode: 127, Err: fmt.Errorf("%w: %s", ErrFixtureNotFound, cmd)}
+	}
+	if c.lineFunc != nil {
+		replayLines(c.lineFunc, StreamStdout, fixture.Stdout)
+		replayLines(c.lineFunc, StreamStderr, fixture.Stderr)
+	}
+	result := &Result{Stdout: fixture.Stdout, Stderr: fixture.Stderr, ExitCode: fixture.ExitCode}
+	if fixture.ExitCode != 0 {
+		result.Err = fmt.Errorf("exit status %d: %w", fixture.ExitCode, ErrBashExec)
+	}
+	return result
+}
+
+// next
+//
+//	@Description: 记录命令并返回第一个未使用的匹配 fixture，都已使用时返回最后一个匹配的
+//	@receiver f
+//	@param cmd
+//	@return Fixture
+//	@return bool 是否有匹配的 fixture
+//	@return error
+func (f *Fake) next(cmd string) (Fixture, bool, error) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.calls = append(f.calls, cmd)
+
+	last := -1
+	for i := range f.fixtures {
+		matched, err := f.fixtures[i].match(cmd)
+		if err != nil {
+			return Fixture{}, false, err
+		}
+		if !matched {
+			continue
+		}
+		if !f.used[i] {
+			f.used[i] = true
+			return f.fixtures[i], true, nil
+		}
+		last = i
+	}
+	if last < 0 {
+		return Fixture{}, false, nil
+	}
+	return f.fixtures[last], true, nil
+}
+
+func (f *Fake) Bash(ctx context.Context, cmd string) (string, int) {
+	if cmd == "" {
+		return "", 0
+	}
+	return combined(f.Run(ctx, NewCommand(cmd)))
+}
+
+func (f *Fake) BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int) {
+	return combined(f.Run(ctx, NewCommand(cmd).WithDir(dir)))
+}
+
+func replayLines(fn LineFunc, stream Stream, output string) {
+	if output == "" {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		fn(Line{Stream: stream, Text: line})
+	}
+}
+
+// Recorder 录制执行器，包装真实的执行器并记录每条命令的结果，保存后可由 LoadFake 回放
+type Recorder struct {
+	Executor Executor
+	mux      sync.Mutex
+	fixtures []Fixture
+}
+
+// NewRecorder
+//
+//	@Description: 创建录制执行器
+//	@param executor 真实的执行器
+//	@return *Recorder
+func NewRecorder(executor Executor) *Recorder {
+	return &Recorder{Executor: executor}
+}
+
+func (r *Recorder) Run(ctx context.Context, c *Command) *Result {
+	result := r.Executor.Run(ctx, c)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.fixtures = append(r.fixtures, Fixture{
+		Command:  c.String(),
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		ExitCode: result.ExitCode,
+	})
+	return result
+}
+
+func (r *Recorder) Bash(ctx context.Context, cmd string) (string, int) {
+	if cmd == "" {
+		return "", 0
+	}
+	return combined(r.Run(ctx, NewCommand(cmd)))
+}
+
+func (r *Recorder) BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int) {
+	return combined(r.Run(ctx, NewCommand(cmd).WithDir(dir)))
+}
+
+// Fixtures 返回录制的所有 fixture
+func (r *Recorder) Fixtures() []Fixture {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]Fixture{}, r.fixtures...)
+}
+
+// Save
+//
+//	@Description: 将录制结果保存为 json 文件
+//	@receiver r
+//	@param file
+//	@return error
+func (r *Recorder) Save(file string) error {
+	data, err := json.MarshalIndent(r.Fixtures(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal fixtures: %w", err)
+	}
+	err = os.WriteFile(file, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("write fixture file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFake_Run(t *testing.T) {
+	fake := NewFake(
+		Fixture{Command: "nmcli connection up ens192", Stdout: "ok\n"},
+		Fixture{Pattern: `^systemctl is-active `, Stdout: "inactive\n", ExitCode: 3},
+	)
+	var lines []Line
+	result := fake.Run(context.Background(), NewArgvCommand("nmcli", "connection", "up", "ens192").WithLineFunc(func(line Line) {
+		lines = append(lines, line)
+	}))
+	if !result.Success() || result.Stdout != "ok\n" || len(lines) != 1 || lines[0].Text != "ok" {
+		t.Errorf("Run() = %+v, lines = %v", result, lines)
+	}
+
+	out, code := fake.Bash(context.Background(), "systemctl is-active docker")
+	if out != "inactive\n" || code != 3 {
+		t.Errorf("Bash() = %q, %d", out, code)
+	}
+
+	result = fake.Run(context.Background(), NewCommand("reboot"))
+	if !errors.Is(result.Err, ErrFixtureNotFound) || result.ExitCode != 127 {
+		t.Errorf("Run() err = %v", result.Err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 3 || calls[2] != "reboot" {
+		t.Errorf("Calls() = %v", calls)
+	}
+}
+
+func TestRecorder_Save(t *testing.T) {
+	recorder := NewRecorder(NewLocal())
+	recorder.Run(context.Background(), NewArgvCommand("echo", "a b"))
+	recorder.Bash(context.Background(), "echo err >&2; exit 2")
+
+	file := filepath.Join(t.TempDir(), "fixtures.json")
+	err := recorder.Save(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake, err := LoadFake(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := fake.Run(context.Background(), NewArgvCommand("echo", "a b"))
+	if result.Stdout != "a b\n" {
+		t.Errorf("Stdout = %q", result.Stdout)
+	}
+	result = fake.Run(context.Background(), NewCommand("echo err >&2; exit 2"))
+	if result.Stderr != "err\n" || result.ExitCode != 2 {
+		t.Errorf("Run() = %+v", result)
+	}
+}
+
+func TestFake_RunInOrder(t *testing.T) {
+	recorder := NewRecorder(NewFake(
+		Fixture{Command: "systemctl is-active docker", Stdout: "activating\n", ExitCode: 3},
+		Fixture{Command: "systemctl is-active docker", Stdout: "active\n"},
+	))
+	for range 3 {
+		recorder.Bash(context.Background(), "systemctl is-active docker")
+	}
+
+	// 录制的轮询结果按顺序回放，用完后一直返回最后一个
+	fake := NewFake(recorder.Fixtures()...)
+	var got []string
+	for range 4 {
+		out, _ := fake.Bash(context.Background(), "systemctl is-active docker")
+		got = append(got, out)
+	}
+	want := []string{"activating\n", "active\n", "active\n", "active\n"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Bash() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,168 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Progress 多主机执行的进度回调，FanOut 会串行调用，实现无需并发安全
+// bar.HostProgress 将每台主机展示为一个 bar
+type Progress interface {
+	// Start 主机开始执行命令
+	Start(host, cmd string)
+	// Update 主机输出了一行
+	Update(host, line string)
+	// Done 主机执行结束，err 为 nil 表示成功
+	Done(host string, exitCode int, duration time.Duration, err error)
+}
+
+// FanOut 在多台主机上并发执行同一条命令
+type FanOut struct {
+	Concurrency int           // 最大并发数，<=0 时不限制
+	Timeout     time.Duration // 单台主机的超时时间，0 表示不超时
+	Progress    Progress      // 可选，展示每台主机的进度
+	progressMux sync.Mutex
+}
+
+// NewFanOut
+//
+//	@Description: 创建并发执行器
+//	@param concurrency 最大并发数
+//	@param timeout 单台主机的超时时间
+//	@param progress 可以为 nil
+//	@return *FanOut
+func NewFanOut(concurrency int, timeout time.Duration, progress Progress) *FanOut {
+	return &FanOut{
+		Concurrency: concurrency,
+		Timeout:     timeout,
+		Progress:    progress,
+	}
+}
+
+// Run
+//
+//	@Description: 在所有主机上执行命令，等待全部完成
+//	@receiver f
+//	@param ctx
+//	@param targets 主机名 -> 执行器
+//	@param c 设置了 stdin 时先读取全部内容，每台主机都会收到完整的 stdin
+//	@return map[string]*Result 主机名 -> 执行结果
+//	@return error 所有失败主机的错误，通过 errors.Join 聚合
+func (f *FanOut) Run(ctx context.Context, targets map[string]Executor, c *Command) (map[string]*Result, error) {
+	// stdin 只能读取一次，读取后每台主机使用各自的 reader
+	var stdin []byte
+	if c.stdin != nil {
+		var err error
+		if stdin, err = io.ReadAll(c.stdin); err != nil {
+			return nil, fmt.Errorf("读取 stdin 失败: %w", err)
+		}
+	}
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 || concurrency > len(targets) {
+		concurrency = len(targets)
+	}
+	sem := make(chan struct{}, concurrency)
+
+	var (
+		wg      sync.WaitGroup
+		mux     sync.Mutex
+		results = make(map[string]*Result, len(targets))
+		errs    []error
+	)
+	for name, executor := range targets {
+		wg.Add(1)
+		go func(name string, executor Executor) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mux.Lock()
+				results[name] = &Result{ExitCode: 1, Err: fmt.Errorf("未执行: %w", ctx.Err())}
+				errs = append(errs, fmt.Errorf("host %s: %w", name, ctx.Err()))
+				mux.Unlock()
+				return
+			}
+
+			hc := *c
+			if c.stdin != nil {
+				hc.stdin = bytes.NewReader(stdin)
+			}
+			result := f.runOne(ctx, name, executor, &hc)
+
+			mux.Lock()
+			defer mux.Unlock()
+			results[name] = result
+			if !result.Success() {
+				errs = append(errs, hostError(name, result))
+			}
+		}(name, executor)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, errors.Join(errs...)
+	}
+	return results, nil
+}
+
+// runOne
+//
+//	@Description: 在单台主机上执行命令，并回调该主机的进度
+//	@receiver f
+//	@return *Result
+func (f *FanOut) runOne(ctx context.Context, name string, executor Executor, c *Command) *Result {
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+	if f.Progress == nil {
+		return executor.Run(ctx, c)
+	}
+
+	f.withProgress(func(p Progress) {
+		p.Start(name, c.String())
+	})
+	// 复制一份命令，每一行输出都回调 Progress
+	hc := *c
+	hc.lineFunc = func(line Line) {
+		if c.lineFunc != nil {
+			c.lineFunc(line)
+		}
+		f.withProgress(func(p Progress) {
+			p.Update(name, line.Text)
+		})
+	}
+	result := executor.Run(ctx, &hc)
+	f.withProgress(func(p Progress) {
+		var err error
+		if !result.Success() {
+			err = hostError(name, result)
+		}
+		p.Done(name, result.ExitCode, result.Duration, err)
+	})
+	return result
+}
+
+// withProgress 串行调用 Progress，实现无需并发安全
+func (f *FanOut) withProgress(fn func(p Progress)) {
+	f.progressMux.Lock()
+	defer f.progressMux.Unlock()
+	fn(f.Progress)
+}
+
+func hostError(name string, result *Result) error {
+	err := result.Err
+	if err == nil {
+		err = ErrBashExec
+	}
+	return fmt.Errorf("host %s, exit.code: %d, stderr: %s, err: %w", name, result.ExitCode, strings.TrimSpace(result.Stderr), err)
+}
@@ -0,0 +1,111 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingExecutor 记录同时执行的最大并发数
+type countingExecutor struct {
+	*Local
+	running, max *atomic.Int32
+}
+
+func (e countingExecutor) Run(ctx context.Context, c *Command) *Result {
+	n := e.running.Add(1)
+	defer e.running.Add(-1)
+	for {
+		m := e.max.Load()
+		if n <= m || e.max.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	return e.Local.Run(ctx, c)
+}
+
+func TestFanOut_Run(t *testing.T) {
+	var running, maxRunning atomic.Int32
+	targets := make(map[string]Executor)
+	for _, name := range []string{"node1", "node2", "node3", "node4"} {
+		targets[name] = countingExecutor{Local: NewLocal(), running: &running, max: &maxRunning}
+	}
+	results, err := NewFanOut(2, 0, nil).Run(context.Background(), targets, NewCommand("sleep 0.1; echo ok"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("results = %v", results)
+	}
+	for name, result := range results {
+		if result.Stdout != "ok\n" {
+			t.Errorf("%s Stdout = %q", name, result.Stdout)
+		}
+	}
+	if maxRunning.Load() != 2 {
+		t.Errorf("max concurrency = %d, want 2", maxRunning.Load())
+	}
+}
+
+func TestFanOut_RunError(t *testing.T) {
+	targets := map[string]Executor{"node1": NewLocal(), "node2": NewLocal()}
+	results, err := NewFanOut(0, 100*time.Millisecond, nil).Run(context.Background(), targets, NewCommand("sleep 5"))
+	if !errors.Is(err, ErrBashTimeout) {
+		t.Fatalf("err = %v, want ErrBashTimeout", err)
+	}
+	if !strings.Contains(err.Error(), "node1") || !strings.Contains(err.Error(), "node2") {
+		t.Errorf("err = %v", err)
+	}
+	for name, result := range results {
+		if result.Success() {
+			t.Errorf("%s should fail", name)
+		}
+	}
+}
+
+// recordProgress 记录 Progress 的回调
+type recordProgress struct {
+	calls []string
+}
+
+func (p *recordProgress) Start(host, cmd string) {
+	p.calls = append(p.calls, "start "+host+" "+cmd)
+}
+
+func (p *recordProgress) Update(host, line string) {
+	p.calls = append(p.calls, "update "+host+" "+line)
+}
+
+func (p *recordProgress) Done(host string, exitCode int, _ time.Duration, err error) {
+	p.calls = append(p.calls, fmt.Sprintf("done %s %d %v", host, exitCode, err == nil))
+}
+
+func TestFanOut_Progress(t *testing.T) {
+	p := &recordProgress{}
+	targets := map[string]Executor{"node1": NewLocal()}
+	if _, err := NewFanOut(0, 0, p).Run(context.Background(), targets, NewCommand("echo a; echo b")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start node1 echo a; echo b", "update node1 a", "update node1 b", "done node1 0 true"}
+	if !slices.Equal(p.calls, want) {
+		t.Errorf("calls = %q, want %q", p.calls, want)
+	}
+}
+
+func TestFanOut_RunStdin(t *testing.T) {
+	targets := map[string]Executor{"node1": NewLocal(), "node2": NewLocal(), "node3": NewLocal()}
+	results, err := NewFanOut(0, 0, nil).Run(context.Background(), targets, NewCommand("cat").WithStdin(strings.NewReader("hello\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, result := range results {
+		if result.Stdout != "hello\n" {
+			t.Errorf("%s Stdout = %q", name, result.Stdout)
+		}
+	}
+}
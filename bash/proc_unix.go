@@ -0,0 +1,22 @@
+//go:build !windows
+
+package bash
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup
+//
+//	@Description: 命令在独立的进程组中运行，ctx 结束时终止整个进程组，
+//	避免 bash -c 启动的子进程继续持有 stdout，导致 Wait 一直阻塞；
+//	独立的进程组收不到终端的 Ctrl-C，只在 ctx 可能结束时使用
+//	@param cmd
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		//nolint:wrapcheck
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
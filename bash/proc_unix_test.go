@@ -0,0 +1,25 @@
+//go:build !windows
+
+package bash
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestProcessGroup(t *testing.T) {
+	pgrp := strconv.Itoa(syscall.Getpgrp())
+	// 没有超时且 ctx 不可取消时留在当前进程组，可以收到终端的 Ctrl-C
+	result := NewCommand("ps -o pgid= -p $$").Run(context.Background())
+	if strings.TrimSpace(result.Stdout) != pgrp {
+		t.Errorf("pgid = %q, want %s", result.Stdout, pgrp)
+	}
+	result = NewCommand("ps -o pgid= -p $$").WithTimeout(time.Minute).Run(context.Background())
+	if got := strings.TrimSpace(result.Stdout); got == pgrp || got == "" {
+		t.Errorf("pgid = %q, want a new process group", got)
+	}
+}
@@ -0,0 +1,6 @@
+package bash
+
+import "os/exec"
+
+// killProcessGroup Windows 没有进程组，ctx 结束时只终止命令本身
+func killProcessGroup(*exec.Cmd) {}
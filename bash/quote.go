@@ -0,0 +1,46 @@
+package bash
+
+import (
+	"strings"
+)
+
+// Quote
+//
+//	@Description: 将参数转义为 bash 可安全使用的单个词，用于拼接 shell 命令
+//	@param s
+//	@return string
+func Quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if isSafe(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// QuoteArgs
+//
+//	@Description: 转义每个参数并以空格连接
+//	@param args
+//	@return string
+func QuoteArgs(args ...string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		quoted = append(quoted, Quote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// isSafe 只包含不需要转义的字符
+func isSafe(s string) bool {
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_@%+=:,./-", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
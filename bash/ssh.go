@@ -0,0 +1,294 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/youcd/toolkit/sysinfo/types"
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	ErrSSHAuthMethod = errors.New("ssh 未配置密码或私钥")
+	ErrSSHHostKey    = errors.New("ssh 未配置主机公钥校验")
+)
+
+const defaultSSHPort = 22
+
+// SSHConfig 远程主机的连接配置，Password 与 PrivateKey 至少设置一个
+type SSHConfig struct {
+	Address         string              // 主机地址
+	Port            int                 // ssh 端口，默认 22
+	User            string              // 用户名，默认 root
+	Password        string              // 密码
+	PrivateKey      []byte              // PEM 格式私钥，可由 sshkey.MakeSSHKeyPair 生成
+	Timeout         time.Duration       // 建立连接和握手的超时时间，默认 10s
+	HostKeyCallback ssh.HostKeyCallback // 主机公钥校验，例如 ssh.FixedHostKey、knownhosts.New
+	// InsecureIgnoreHostKey 不校验主机公钥，HostKeyCallback 为空时需显式设置，存在中间人攻击的风险
+	InsecureIgnoreHostKey bool
+}
+
+// SSH 远程执行器，通过 ssh 在远程主机上执行命令
+type SSH struct {
+	client *ssh.Client
+}
+
+// NewSSH
+//
+//	@Description: 连接远程主机
+//	@param ctx
+//	@param cfg
+//	@return *SSH
+//	@return error
+func NewSSH(ctx context.Context, cfg *SSHConfig) (*SSH, error) {
+	var auth []ssh.AuthMethod
+	if len(cfg.PrivateKey) > 0 {
+		signer, err := ssh.ParsePrivateKey(cfg.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("ssh.ParsePrivateKey: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, ErrSSHAuthMethod
+	}
+
+	user := cfg.User
+	if user == "" {
+		user = "root"
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = defaultSSHPort
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	hostKeyCallback := cfg.HostKeyCallback
+	switch {
+	case hostKeyCallback != nil:
+	case cfg.InsecureIgnoreHostKey:
+		//nolint:gosec
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, ErrSSHHostKey
+	}
+
+	addr := net.JoinHostPort(cfg.Address, strconv.Itoa(port))
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", addr, err)
+	}
+
+	// 握手在原始连接上进行，ssh.ClientConfig.Timeout 只作用于 Dial，需自行设置超时并响应 ctx
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if !stop() {
+		// ctx 已结束，连接已被关闭
+		if err == nil {
+			_ = c.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake %s: %w", addr, ctx.Err())
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("ssh handshake %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return &SSH{client: ssh.NewClient(c, chans, reqs)}, nil
+}
+
+// NewSSHFromHost
+//
+//	@Description: 使用主机信息连接远程主机
+//	@param ctx
+//	@param h
+//	@param privateKey 为空时使用 h.Password 认证
+//	@param hostKeyCallback 主机公钥校验，不能为空，不校验时显式传入 ssh.InsecureIgnoreHostKey()
+//	@return *SSH
+//	@return error
+func NewSSHFromHost(ctx context.Context, h *types.Host, privateKey []byte, hostKeyCallback ssh.HostKeyCallback) (*SSH, error) {
+	cfg := &SSHConfig{
+		User:            h.User,
+		Password:        h.Password,
+		PrivateKey:      privateKey,
+		HostKeyCallback: hostKeyCallback,
+	}
+	if h.IP != nil {
+		cfg.Address = h.IP.Address
+		cfg.Port = h.IP.Port
+	}
+	return NewSSH(ctx, cfg)
+}
+
+// Close 关闭连接
+func (s *SSH) Close() error {
+	//nolint:wrapcheck
+	return s.client.Close()
+}
+
+// Run
+//
+//	@Description: 在远程主机执行命令
+//	@receiver s
+//	@param ctx
+//	@param c
+//	@return *Result
+func (s *SSH) Run(ctx context.Context, c *Command) *Result {
+	start := time.Now()
+	result := &Result{}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var stdout, stderr bytes.Buffer
+	stdoutWriter, stderrWriter, flush := c.writers(ctx, &stdout, &stderr)
+	err := s.run(ctx, remoteCommand(c), c.stdin, stdoutWriter, stderrWriter)
+	flush()
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	result.ExitCode, result.Err = exitCode(ctx, err)
+	return result
+}
+
+// Bash
+//
+//	@Description: 在远程主机执行bash命令
+//	@receiver s
+//	@param ctx
+//	@param cmd
+//	@return out
+//	@return exitCode
+func (s *SSH) Bash(ctx context.Context, cmd string) (string, int) {
+	if cmd == "" {
+		return "", 0
+	}
+	return s.combinedOutput(ctx, NewCommand(cmd))
+}
+
+// BashWithWorkDir
+//
+//	@Description: 在远程主机指定工作目录执行bash命令
+//	@receiver s
+//	@param ctx
+//	@param cmd
+//	@param dir
+//	@return out
+//	@return exitCode
+func (s *SSH) BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int) {
+	return s.combinedOutput(ctx, NewCommand(cmd).WithDir(dir))
+}
+
+func (s *SSH) combinedOutput(ctx context.Context, c *Command) (string, int) {
+	var output lockedBuffer
+	err := s.run(ctx, remoteCommand(c), nil, &output, &output)
+	if err != nil {
+		var exitErr *ssh.ExitError
+		if errors.As(err, &exitErr) {
+			return output.String(), exitErr.ExitStatus()
+		}
+		return err.Error(), 1
+	}
+	return output.String(), 0
+}
+
+// run
+//
+//	@Description: 新建 session 执行命令，ctx 结束时终止远程进程
+//	@receiver s
+//	@return error 退出码非0时为 *ssh.ExitError
+func (s *SSH) run(ctx context.Context, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	session, err := s.client.NewSession()
+	if err != nil {
+		return fmt.Errorf("ssh new session: %w", err)
+	}
+	defer session.Close()
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Signal(ssh.SIGKILL)
+			_ = session.Close()
+		case <-done:
+		}
+	}()
+
+	err = session.Run(cmd)
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("ssh run: %w", ctx.Err())
+	}
+	//nolint:wrapcheck
+	return err
+}
+
+// remoteCommand
+//
+//	@Description: 将 Command 转换为远程执行的 shell 命令，包含工作目录与环境变量
+//	@param c
+//	@return string
+func remoteCommand(c *Command) string {
+	var b strings.Builder
+	if c.dir != "" {
+		b.WriteString("mkdir -p " + Quote(c.dir) + " && cd " + Quote(c.dir) + " && ")
+	}
+	b.WriteString("env " + QuoteArgs(append(append([]string{}, defaultEnv...), c.env...)...) + " ")
+	if c.argv != nil {
+		b.WriteString(QuoteArgs(c.argv...))
+	} else {
+		b.WriteString("bash -c " + Quote(c.cmd))
+	}
+	return b.String()
+}
+
+// lockedBuffer stdout 与 stderr 并发写入同一个 buffer
+type lockedBuffer struct {
+	mux sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	//nolint:wrapcheck
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,217 @@
+package bash
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/youcd/toolkit/sshkey"
+	"github.com/youcd/toolkit/sysinfo/types"
+	"golang.org/x/crypto/ssh"
+)
+
+// newTestSSHServer 启动一个进程内的 ssh 服务，exec 请求在本机通过 bash 执行
+func newTestSSHServer(t *testing.T, password string, authorizedKey ssh.PublicKey) (*types.IP, ssh.PublicKey) {
+	t.Helper()
+	private, _, err := sshkey.GenerateKey(2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostKey, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) == password {
+				return nil, nil
+			}
+			return nil, errors.New("wrong password")
+		},
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if authorizedKey != nil && string(key.Marshal()) == string(authorizedKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unknown key")
+		},
+	}
+	cfg.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveTestSSHConn(conn, cfg)
+		}
+	}()
+	//nolint:forcetypeassert
+	return &types.IP{Address: "127.0.0.1", Port: l.Addr().(*net.TCPAddr).Port}, hostKey.PublicKey()
+}
+
+func serveTestSSHConn(conn net.Conn, cfg *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, cfg)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			defer channel.Close()
+			for req := range requests {
+				if req.Type != "exec" {
+					_ = req.Reply(false, nil)
+					continue
+				}
+				_ = req.Reply(true, nil)
+				cmd := exec.Command("bash", "-c", string(req.Payload[4:]))
+				cmd.Stdin = channel
+				cmd.Stdout = channel
+				cmd.Stderr = channel.Stderr()
+				status := make([]byte, 4)
+				if err := cmd.Run(); err != nil {
+					code := 1
+					var exitErr *exec.ExitError
+					if errors.As(err, &exitErr) {
+						code = exitErr.ExitCode()
+					}
+					binary.BigEndian.PutUint32(status, uint32(code))
+				}
+				_ = channel.CloseWrite()
+				_, _ = channel.SendRequest("exit-status", false, status)
+				return
+			}
+		}()
+	}
+}
+
+func TestSSH_Password(t *testing.T) {
+	ip, hostKey := newTestSSHServer(t, "secret", nil)
+	h := &types.Host{IP: ip, User: "test", Password: "secret"}
+	s, err := NewSSHFromHost(context.Background(), h, nil, ssh.FixedHostKey(hostKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var executor Executor = s
+	out, code := executor.Bash(context.Background(), "echo hello; exit 2")
+	if out != "hello\n" || code != 2 {
+		t.Errorf("Bash() = %q, %d", out, code)
+	}
+
+	dir := t.TempDir() + "/work dir"
+	out, code = executor.BashWithWorkDir(context.Background(), "pwd", dir)
+	if strings.TrimSpace(out) != dir || code != 0 {
+		t.Errorf("BashWithWorkDir() = %q, %d", out, code)
+	}
+
+	result := executor.Run(context.Background(), NewCommand(`echo "$FOO"; cat; echo err >&2`).
+		WithEnv("FOO=a b").
+		WithStdin(strings.NewReader("input")))
+	if !result.Success() || result.Stdout != "a b\ninput" || result.Stderr != "err\n" {
+		t.Errorf("Run() = %+v", result)
+	}
+
+	result = executor.Run(context.Background(), NewArgvCommand("sh", "-c", "exit 7"))
+	if result.ExitCode != 7 {
+		t.Errorf("Run() ExitCode = %d, err = %v", result.ExitCode, result.Err)
+	}
+
+	result = executor.Run(context.Background(), NewCommand("sleep 5").WithTimeout(200*time.Millisecond))
+	if !errors.Is(result.Err, ErrBashTimeout) {
+		t.Errorf("Run() err = %v, want ErrBashTimeout", result.Err)
+	}
+}
+
+func TestSSH_PrivateKey(t *testing.T) {
+	private, public, err := sshkey.MakeSSHKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authorizedKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip, _ := newTestSSHServer(t, "", authorizedKey)
+	s, err := NewSSH(context.Background(), &SSHConfig{Address: ip.Address, Port: ip.Port, PrivateKey: []byte(private), InsecureIgnoreHostKey: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	out, code := s.Bash(context.Background(), "echo ok")
+	if out != "ok\n" || code != 0 {
+		t.Errorf("Bash() = %q, %d", out, code)
+	}
+
+	_, err = NewSSH(context.Background(), &SSHConfig{Address: ip.Address, Port: ip.Port})
+	if !errors.Is(err, ErrSSHAuthMethod) {
+		t.Errorf("NewSSH() err = %v, want ErrSSHAuthMethod", err)
+	}
+	_, err = NewSSH(context.Background(), &SSHConfig{Address: ip.Address, Port: ip.Port, PrivateKey: []byte(private)})
+	if !errors.Is(err, ErrSSHHostKey) {
+		t.Errorf("NewSSH() err = %v, want ErrSSHHostKey", err)
+	}
+}
+
+func TestSSH_HandshakeTimeout(t *testing.T) {
+	// 接受连接但不进行 ssh 握手的服务端
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	//nolint:forcetypeassert
+	port := l.Addr().(*net.TCPAddr).Port
+
+	for name, tc := range map[string]struct {
+		ctxTimeout time.Duration
+		timeout    time.Duration
+	}{
+		"ctx":    {ctxTimeout: 300 * time.Millisecond},
+		"config": {timeout: 300 * time.Millisecond},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.ctxTimeout)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err := NewSSH(ctx, &SSHConfig{
+				Address: "127.0.0.1", Port: port, Password: "x", Timeout: tc.timeout, InsecureIgnoreHostKey: true,
+			})
+			if err == nil {
+				t.Fatal("NewSSH() should fail")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("NewSSH() took %s", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package bash
+
+import (
+	"bytes"
+	"context"
+	"sync"
+)
+
+// Stream 输出来源
+type Stream int
+
+const (
+	StreamStdout Stream = iota + 1
+	StreamStderr
+)
+
+// String 返回 stdout、stderr，未知的值返回 unknown
+func (s Stream) String() string {
+	switch s {
+	case StreamStdout:
+		return "stdout"
+	case StreamStderr:
+		return "stderr"
+	default:
+		return "unknown"
+	}
+}
+
+// Line 命令输出的一行，不包含换行符
+type Line struct {
+	Stream Stream
+	Text   string
+}
+
+// LineFunc 每输出一行回调一次，stdout 与 stderr 的回调是串行的，在单独的 goroutine 中调用，不会阻塞命令的输出
+type LineFunc func(line Line)
+
+// LineChan
+//
+//	@Description: 将每行输出写入 channel，可直接对接 sse.Sse 的消息 channel；ctx 结束后丢弃剩余的行，避免没有消费者时一直阻塞
+//	@param ctx
+//	@param ch
+//	@return LineFunc
+func LineChan(ctx context.Context, ch chan<- string) LineFunc {
+	return func(line Line) {
+		select {
+		case ch <- line.Text:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// BashStream
+//
+//	@Description: 执行bash命令，并实时回调每一行输出
+//	@param ctx
+//	@param cmd
+//	@param fn
+//	@return *Result
+func BashStream(ctx context.Context, cmd string, fn LineFunc) *Result {
+	return NewCommand(cmd).WithLineFunc(fn).Run(ctx)
+}
+
+// lineDispatcher 在单独的 goroutine 中串行调用回调，回调较慢时行缓存在队列中，不阻塞读取命令的输出
+type lineDispatcher struct {
+	fn     LineFunc
+	mux    sync.Mutex
+	queue  []Line
+	closed bool
+	signal chan struct{}
+	done   chan struct{}
+}
+
+func newLineDispatcher(fn LineFunc) *lineDispatcher {
+	d := &lineDispatcher{
+		fn:     fn,
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+func (d *lineDispatcher) run() {
+	defer close(d.done)
+	for {
+		d.mux.Lock()
+		lines, closed := d.queue, d.closed
+		d.queue = nil
+		d.mux.Unlock()
+		for _, line := range lines {
+			d.fn(line)
+		}
+		if len(lines) > 0 {
+			continue
+		}
+		if closed {
+			return
+		}
+		<-d.signal
+	}
+}
+
+func (d *lineDispatcher) push(lines ...Line) {
+	if len(lines) == 0 {
+		return
+	}
+	d.mux.Lock()
+	d.queue = append(d.queue, lines...)
+	d.mux.Unlock()
+	d.notify()
+}
+
+func (d *lineDispatcher) notify() {
+	select {
+	case d.signal <- struct{}{}:
+	default:
+	}
+}
+
+// close
+//
+//	@Description: 不再接收新的行，等待已缓存的行回调完成，ctx 结束时不再等待
+//	@receiver d
+//	@param ctx
+func (d *lineDispatcher) close(ctx context.Context) {
+	d.mux.Lock()
+	d.closed = true
+	d.mux.Unlock()
+	d.notify()
+	select {
+	case <-d.done:
+	case <-ctx.Done():
+	}
+}
+
+// lineWriter 按行切分写入的数据交给 lineDispatcher，未结束的行缓存到下次写入或 flush
+type lineWriter struct {
+	stream     Stream
+	dispatcher *lineDispatcher
+	mux        sync.Mutex
+	buf        []byte
+}
+
+func newLineWriters(dispatcher *lineDispatcher) (*lineWriter, *lineWriter) {
+	return &lineWriter{stream: StreamStdout, dispatcher: dispatcher},
+		&lineWriter{stream: StreamStderr, dispatcher: dispatcher}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	w.buf = append(w.buf, p...)
+	var lines []Line
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, w.line(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	w.mux.Unlock()
+	w.dispatcher.push(lines...)
+	return len(p), nil
+}
+
+// flush
+//
+//	@Description: 输出最后一行没有换行符的内容
+//	@receiver w
+func (w *lineWriter) flush() {
+	w.mux.Lock()
+	var lines []Line
+	if len(w.buf) > 0 {
+		lines = append(lines, w.line(w.buf))
+		w.buf = nil
+	}
+	w.mux.Unlock()
+	w.dispatcher.push(lines...)
+}
+
+func (w *lineWriter) line(line []byte) Line {
+	return Line{Stream: w.stream, Text: string(bytes.TrimSuffix(line, []byte("\r")))}
+}
@@ -0,0 +1,222 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/youcd/toolkit/sysinfo/types"
+)
+
+var (
+	ErrSudoWrongPassword    = errors.New("sudo 密码错误")
+	ErrSudoNotInSudoers     = errors.New("用户不在 sudoers 中")
+	ErrSudoPasswordRequired = errors.New("sudo 需要密码")
+)
+
+// sudoPrompt 自定义的密码提示，便于从 stderr 中去除
+const sudoPrompt = "[sudo-password-prompt]"
+
+// Sudo 通过 sudo 提权执行命令，实现了 Executor 可与 FanOut 配合使用
+//
+// 执行命令前先用 sudo -n true 检查是否需要密码，需要时用 sudo -S -v 单独校验密码，
+// 校验通过后才将密码写在命令的标准输入之前，密码错误时不会把调用方的标准输入当作重试的密码
+//
+// 注意: sudoers 只对部分命令配置 NOPASSWD 时，密码行会作为这些命令标准输入的第一行
+type Sudo struct {
+	Executor Executor // 实际执行命令的执行器，本机或 ssh
+	User     string   // 登录用户，root 用户不使用 sudo
+	Password string   // sudo 密码
+}
+
+// NewSudo
+//
+//	@Description: 创建 sudo 执行器
+//	@param executor
+//	@param password
+//	@return *Sudo
+func NewSudo(executor Executor, password string) *Sudo {
+	return &Sudo{Executor: executor, Password: password}
+}
+
+// NewSudoFromHost
+//
+//	@Description: 使用主机的用户和密码创建 sudo 执行器
+//	@param executor
+//	@param h
+//	@return *Sudo
+func NewSudoFromHost(executor Executor, h *types.Host) *Sudo {
+	return &Sudo{Executor: executor, User: h.User, Password: h.Password}
+}
+
+// Run
+//
+//	@Description: 以 root 权限执行命令，工作目录在 sudo 内创建，可位于 /opt 等 root 所有的目录下
+//	@receiver s
+//	@param ctx
+//	@param c
+//	@return *Result 密码错误、不在 sudoers 中时 Err 分别包装 ErrSudoWrongPassword、ErrSudoNotInSudoers
+func (s *Sudo) Run(ctx context.Context, c *Command) *Result {
+	if s.User == "root" {
+		return s.Executor.Run(ctx, c)
+	}
+
+	needPassword, result := s.needPassword(ctx)
+	if result != nil {
+		return result
+	}
+
+	args := []string{"-n"}
+	var stdin io.Reader
+	if needPassword {
+		// -k 忽略缓存的凭据，保证 sudo 总是读取且只读取一行密码
+		args = []string{"-S", "-k", "-p", sudoPrompt}
+		stdin = strings.NewReader(s.Password + "\n")
+		if c.stdin != nil {
+			stdin = io.MultiReader(stdin, c.stdin)
+		}
+	} else {
+		stdin = c.stdin
+	}
+	args = append(args, "--", "env")
+	args = append(args, defaultEnv...)
+	args = append(args, c.env...)
+	switch {
+	case c.dir != "":
+		// 在 sudo 内创建并进入工作目录
+		inner := "mkdir -p " + Quote(c.dir) + " && cd " + Quote(c.dir) + " && exec "
+		if c.argv != nil {
+			inner += QuoteArgs(c.argv...)
+		} else {
+			inner += "bash -c " + Quote(c.cmd)
+		}
+		args = append(args, "bash", "-c", inner)
+	case c.argv != nil:
+		args = append(args, c.argv...)
+	default:
+		args = append(args, "bash", "-c", c.cmd)
+	}
+	sc := *c
+	sc.cmd = ""
+	sc.argv = append([]string{"sudo"}, args...)
+	sc.env = nil
+	sc.dir = ""
+	sc.stdin = stdin
+
+	result = s.Executor.Run(ctx, &sc)
+	result.Stderr = strings.ReplaceAll(result.Stderr, sudoPrompt, "")
+	if result.ExitCode != 0 {
+		if err := sudoError(result.Stderr); err != nil {
+			result.Err = err
+		}
+	}
+	return result
+}
+
+// needPassword
+//
+//	@Description: 检查 sudo 是否需要密码，需要时校验密码
+//	@receiver s
+//	@param ctx
+//	@return bool 是否需要密码
+//	@return *Result 不为空时为检查失败的结果
+func (s *Sudo) needPassword(ctx context.Context) (bool, *Result) {
+	result := s.Executor.Run(ctx, NewArgvCommand("sudo", "-n", "--", "true"))
+	if result.Success() {
+		return false, nil
+	}
+	if err := sudoError(result.Stderr); err != nil && !errors.Is(err, ErrSudoPasswordRequired) {
+		result.Err = err
+		return true, result
+	}
+	if s.Password == "" {
+		result.Err = fmt.Errorf("%w: %s", ErrSudoPasswordRequired, strings.TrimSpace(result.Stderr))
+		return true, result
+	}
+
+	// 标准输入只有一行密码，密码错误时 sudo 重试会读到 EOF 并退出
+	result = s.Executor.Run(ctx, NewArgvCommand("sudo", "-S", "-k", "-v", "-p", sudoPrompt).
+		WithStdin(strings.NewReader(s.Password+"\n")))
+	result.Stderr = strings.ReplaceAll(result.Stderr, sudoPrompt, "")
+	if result.Success() {
+		return true, nil
+	}
+	if err := sudoError(result.Stderr); err != nil {
+		result.Err = err
+	} else if result.Err == nil {
+		result.Err = fmt.Errorf("%w: %s", ErrSudoWrongPassword, strings.TrimSpace(result.Stderr))
+	}
+	return true, result
+}
+
+// Script
+//
+//	@Description: 以 root 权限执行多行脚本
+//	@receiver s
+//	@param ctx
+//	@param script
+//	@return *Result
+func (s *Sudo) Script(ctx context.Context, script string) *Result {
+	return s.Run(ctx, NewCommand(script))
+}
+
+// Bash
+//
+//	@Description: 以 root 权限执行bash命令
+//	@receiver s
+//	@param ctx
+//	@param cmd
+//	@return out stdout 与 stderr 依次拼接
+//	@return exitCode
+func (s *Sudo) Bash(ctx context.Context, cmd string) (string, int) {
+	if cmd == "" {
+		return "", 0
+	}
+	return combined(s.Run(ctx, NewCommand(cmd)))
+}
+
+// BashWithWorkDir
+//
+//	@Description: 以 root 权限在指定工作目录执行bash命令
+//	@receiver s
+//	@param ctx
+//	@param cmd
+//	@param dir
+//	@return out stdout 与 stderr 依次拼接
+//	@return exitCode
+func (s *Sudo) BashWithWorkDir(ctx context.Context, cmd, dir string) (string, int) {
+	return combined(s.Run(ctx, NewCommand(cmd).WithDir(dir)))
+}
+
+func combined(result *Result) (string, int) {
+	if result.Err != nil && result.ExitCode == 0 {
+		return result.Err.Error(), 1
+	}
+	return result.Stdout + result.Stderr, result.ExitCode
+}
+
+// sudoError
+//
+//	@Description: 从 sudo 的 stderr 中识别错误类型，只匹配 sudo 自身输出的行，不匹配命令的输出
+//	@param stderr
+//	@return error
+func sudoError(stderr string) error {
+	for _, line := range strings.Split(stderr, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "Sorry, try again.",
+			strings.HasPrefix(line, "sudo: ") && strings.Contains(line, "incorrect password attempt"):
+			return fmt.Errorf("%w: %s", ErrSudoWrongPassword, line)
+		case strings.HasSuffix(line, "is not in the sudoers file."),
+			strings.Contains(line, "is not in the sudoers file.  This incident will be reported"),
+			strings.HasPrefix(line, "Sorry, user ") && strings.Contains(line, " is not allowed to execute "):
+			return fmt.Errorf("%w: %s", ErrSudoNotInSudoers, line)
+		case line == "sudo: a password is required",
+			line == "sudo: no password was provided":
+			return fmt.Errorf("%w: %s", ErrSudoPasswordRequired, line)
+		}
+	}
+	return nil
+}
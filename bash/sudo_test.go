@@ -0,0 +1,148 @@
+package bash
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSudo 模拟 sudo 的行为: 密码为 right，最多尝试 3 次；用户 nobody 不在 sudoers 中；
+// FAKE_SUDO_NOPASSWD=1 时不需要密码；每次调用的参数追加到 FAKE_SUDO_LOG
+const fakeSudo = `#!/bin/bash
+[ -n "$FAKE_SUDO_LOG" ] && echo "$*" >> "$FAKE_SUDO_LOG"
+while [ $# -gt 0 ] && [ "$1" != "--" ]; do
+	case "$1" in
+	-p) shift; prompt="$1" ;;
+	-n) nonint=1 ;;
+	-v) validate=1 ;;
+	esac
+	shift
+done
+[ $# -gt 0 ] && shift
+if [ "$FAKE_SUDO_USER" = "nobody" ]; then
+	echo "nobody is not in the sudoers file.  This incident will be reported." >&2
+	exit 1
+fi
+if [ "$FAKE_SUDO_NOPASSWD" != "1" ]; then
+	if [ -n "$nonint" ]; then
+		echo "sudo: a password is required" >&2
+		exit 1
+	fi
+	for i in 1 2 3; do
+		printf '%s' "$prompt" >&2
+		read -r password || break
+		if [ "$password" = "right" ]; then ok=1; break; fi
+		echo "Sorry, try again." >&2
+	done
+	if [ -z "$ok" ]; then
+		echo "sudo: 1 incorrect password attempt" >&2
+		exit 1
+	fi
+fi
+[ -n "$validate" ] && exit 0
+exec "$@"
+`
+
+func setupFakeSudo(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "sudo"), []byte(fakeSudo), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+}
+
+func TestSudo_Script(t *testing.T) {
+	setupFakeSudo(t)
+	s := NewSudo(NewLocal(), "right")
+	result := s.Script(context.Background(), "echo one\necho \"$FOO\"\ncat")
+	if !result.Success() {
+		t.Fatalf("Script() err = %v, stderr = %s", result.Err, result.Stderr)
+	}
+	if result.Stdout != "one\n\n" || result.Stderr != "" {
+		t.Errorf("Script() = %+v", result)
+	}
+
+	result = s.Run(context.Background(), NewCommand(`echo "$FOO"; cat`).
+		WithEnv("FOO=bar").
+		WithStdin(strings.NewReader("input")))
+	if result.Stdout != "bar\ninput" {
+		t.Errorf("Run() Stdout = %q", result.Stdout)
+	}
+
+	out, code := s.Bash(context.Background(), "echo ok; exit 3")
+	if out != "ok\n" || code != 3 {
+		t.Errorf("Bash() = %q, %d", out, code)
+	}
+}
+
+func TestSudo_Errors(t *testing.T) {
+	setupFakeSudo(t)
+	result := NewSudo(NewLocal(), "wrong").Script(context.Background(), "echo ok")
+	if !errors.Is(result.Err, ErrSudoWrongPassword) || result.Stdout != "" {
+		t.Errorf("err = %v, want ErrSudoWrongPassword", result.Err)
+	}
+
+	result = NewSudo(NewLocal(), "").Script(context.Background(), "echo ok")
+	if !errors.Is(result.Err, ErrSudoPasswordRequired) {
+		t.Errorf("err = %v, want ErrSudoPasswordRequired", result.Err)
+	}
+
+	t.Setenv("FAKE_SUDO_USER", "nobody")
+	result = NewSudo(NewLocal(), "right").Script(context.Background(), "echo ok")
+	if !errors.Is(result.Err, ErrSudoNotInSudoers) {
+		t.Errorf("err = %v, want ErrSudoNotInSudoers", result.Err)
+	}
+}
+
+func TestSudo_WrongPasswordKeepsStdin(t *testing.T) {
+	setupFakeSudo(t)
+	// 标准输入的第二行恰好是正确的密码，重试时不能读取调用方的标准输入
+	result := NewSudo(NewLocal(), "wrong").Run(context.Background(), NewCommand("cat").
+		WithStdin(strings.NewReader("right\nright\n")))
+	if !errors.Is(result.Err, ErrSudoWrongPassword) || result.Stdout != "" {
+		t.Errorf("Run() = %+v", result)
+	}
+}
+
+func TestSudo_NoPasswd(t *testing.T) {
+	setupFakeSudo(t)
+	t.Setenv("FAKE_SUDO_NOPASSWD", "1")
+	result := NewSudo(NewLocal(), "right").Run(context.Background(), NewCommand("cat").
+		WithStdin(strings.NewReader("input")))
+	if !result.Success() || result.Stdout != "input" {
+		t.Errorf("Run() = %+v", result)
+	}
+}
+
+func TestSudo_OutputLooksLikeError(t *testing.T) {
+	setupFakeSudo(t)
+	// 命令成功时输出中的 sudo 错误字样不影响结果
+	result := NewSudo(NewLocal(), "right").Script(context.Background(), "echo 'Sorry, try again.' >&2; echo 'x is not allowed to execute' >&2")
+	if !result.Success() {
+		t.Errorf("Script() = %+v", result)
+	}
+}
+
+func TestSudo_WorkDir(t *testing.T) {
+	setupFakeSudo(t)
+	log := filepath.Join(t.TempDir(), "sudo.log")
+	t.Setenv("FAKE_SUDO_LOG", log)
+	dir := filepath.Join(t.TempDir(), "work dir")
+	out, code := NewSudo(NewLocal(), "right").BashWithWorkDir(context.Background(), "pwd", dir)
+	if strings.TrimSpace(out) != dir || code != 0 {
+		t.Errorf("BashWithWorkDir() = %q, %d", out, code)
+	}
+	// 工作目录在 sudo 内创建
+	data, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "mkdir -p '"+dir+"' && cd '"+dir+"'") {
+		t.Errorf("sudo args = %s", data)
+	}
+}
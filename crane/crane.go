@@ -18,6 +18,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/daemon"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/youcd/toolkit/bar"
 )
 
 var ErrBadName = errors.New("镜像名称错误")
@@ -113,6 +114,22 @@ type renameFunc func(imageName string) string
 //	@param imageNames
 //	@return error
 func Write2TarballFile(outputFilePath string, renameRegistry renameFunc, imageNames ...string) error {
+	return write2TarballFile(outputFilePath, renameRegistry, nil, imageNames...)
+}
+
+// Write2TarballFileWithProgress
+//
+//	@Description: 将镜像写入 Tarball 文件，并通过 progress 展示写入进度
+//	@param outputFilePath
+//	@param renameRegistry
+//	@param progress
+//	@param imageNames
+//	@return error
+func Write2TarballFileWithProgress(outputFilePath string, renameRegistry renameFunc, progress *bar.Progress, imageNames ...string) error {
+	return write2TarballFile(outputFilePath, renameRegistry, progress, imageNames...)
+}
+
+func write2TarballFile(outputFilePath string, renameRegistry renameFunc, progress *bar.Progress, imageNames ...string) error {
 	var errs []error
 	// 定义要保存的输出 tar 文件路径
 	imgMap := make(map[string]v1.Image, len(imageNames))
@@ -139,7 +156,12 @@ func Write2TarballFile(outputFilePath string, renameRegistry renameFunc, imageNa
 		imgMap[newName] = img
 	}
 
-	err := crane.MultiSave(imgMap, outputFilePath)
+	var err error
+	if progress == nil {
+		err = crane.MultiSave(imgMap, outputFilePath)
+	} else {
+		err = multiSaveWithProgress(imgMap, outputFilePath, progress)
+	}
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -150,6 +172,43 @@ func Write2TarballFile(outputFilePath string, renameRegistry renameFunc, imageNa
 	return nil
 }
 
+// multiSaveWithProgress
+//
+//	@Description: 与 crane.MultiSave 相同，写入过程中更新 progress
+//	@param imgMap
+//	@param outputFilePath
+//	@param progress
+//	@return error
+func multiSaveWithProgress(imgMap map[string]v1.Image, outputFilePath string, progress *bar.Progress) error {
+	refToImage := make(map[name.Reference]v1.Image, len(imgMap))
+	for src, img := range imgMap {
+		ref, err := name.ParseReference(src)
+		if err != nil {
+			return fmt.Errorf("parsing ref %q: %w", src, err)
+		}
+		refToImage[ref] = img
+	}
+
+	updates := make(chan v1.Update, 16)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for update := range updates {
+			if update.Total > 0 && update.Total != progress.Total() {
+				progress.SetTotal(update.Total)
+			}
+			progress.Set(update.Complete)
+		}
+	}()
+	err := tarball.MultiRefWriteToFile(outputFilePath, refToImage, tarball.WithProgress(updates))
+	close(updates)
+	<-done
+	if err != nil {
+		return fmt.Errorf("write tarball: %w", err)
+	}
+	return nil
+}
+
 type state int
 
 const (
@@ -1,13 +1,14 @@
 package db
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
@@ -17,52 +18,146 @@ var (
 	db *gorm.DB
 )
 
-// InitDB
+var ErrNoDatabase = errors.New("未配置数据库")
+
+// 连接池的默认参数
+const (
+	DefaultMaxOpenConns = 100
+	DefaultMaxIdleConns = 20
+	DefaultCharset      = "utf8mb4"
+	DefaultTimezone     = "Local"
+	// tlsConfigPrefix 注册到 mysql 驱动的自定义 TLS 配置名称的前缀，每个 *tls.Config 注册一个名称
+	tlsConfigPrefix = "toolkit-db"
+)
+
+// Config 数据库配置，零值的字段使用默认值
+type Config struct {
+	Driver Driver // 默认 DriverMySQL
+	DSN    string // 不为空时直接使用，忽略 User、Password、Host、Port、Name、Charset、Timezone、TLS、Params
+
+	User     string
+	Password string
+	Host     string
+	Port     string
+	Name     string            // 数据库名，SQLite 为文件路径或 :memory:
+	Charset  string            // MySQL 默认 DefaultCharset，PostgreSQL 为 client_encoding
+	Timezone string            // 解析时间使用的时区，例如 Asia/Shanghai，MySQL 默认 DefaultTimezone
+	TLS      string            // tls 参数：true、false、skip-verify、preferred，PostgreSQL 也可以为 sslmode，TLSConfig 不为空时忽略
+	Params   map[string]string // 其他连接参数
+
+	TLSConfig *tls.Config // 自定义 TLS 配置，优先于 TLS，SQLite 不支持
+
+	MaxOpenConns    int           // 最大连接数，默认 DefaultMaxOpenConns，SQLite 默认为 1
+	MaxIdleConns    int           // 最大空闲连接数，默认 DefaultMaxIdleConns，不超过最大连接数
+	ConnMaxLifetime time.Duration // 连接的最大存活时间，为 0 时不限制
+	ConnMaxIdleTime time.Duration // 连接的最大空闲时间，为 0 时不限制
+	PingTimeout     time.Duration // 连接和 Ping 的超时时间，为 0 时不限制
+
+	Logger logger.Interface // 为空时不输出 SQL
+}
+
+// Open
 //
-//	@Description: 初始化函数，golang特性，每个包初始化的时候会自动执行init函数，这里用来初始化gorm。
-//	@param DBUser
-//	@param DBPwd
-//	@param DBHost
-//	@param DBPort
-//	@param DBName
-func InitDB(user, pwd, host, port, name string, l logger.Interface) {
-	DSN := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", user, pwd, host, port, name) // 连接数据库
-	if pwd == "" {
-		DSN = fmt.Sprintf("%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local", user, host, port, name) // 连接数据库
+//	@Description: 按配置连接数据库并设置连接池，不修改 GetDB 返回的全局 db
+//	@param c
+//	@return *gorm.DB
+//	@return error
+func Open(c *Config) (*gorm.DB, error) {
+	if c == nil {
+		return nil, ErrNoDatabase
 	}
-
+	dialector, err := c.dialector()
+	if err != nil {
+		return nil, err
+	}
+	l := c.Logger
 	if l == nil {
-		l = logger.New(
-			log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
-			logger.Config{
-				SlowThreshold: time.Second,   // 慢 SQL 阈值
-				LogLevel:      logger.Silent, // Log level
-				Colorful:      false,         // 禁用彩色打印
-			},
-		)
+		l = logger.Discard
 	}
 
-	var err error
-	// 连接MYSQL, 获得DB类型实例，用于后面的数据库读写操作。
-	db, err = gorm.Open(mysql.Open(DSN), &gorm.Config{
-		Logger: l,
+	// 只由下面带超时的 PingContext 检查连接，避免 gorm 的自动 Ping 没有超时
+	gdb, err := gorm.Open(dialector, &gorm.Config{
+		Logger:               l,
+		DisableAutomaticPing: true,
 	})
 	if err != nil {
-		log.Println("连接数据库失败, error=" + err.Error())
-		os.Exit(1)
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
 	}
-	// 设置数据库连接池参数
 
-	sqlDB, err := db.DB()
+	sqlDB, err := gdb.DB()
 	if err != nil {
-		log.Println(err)
-		os.Exit(1)
+		return nil, fmt.Errorf("获取数据库连接池失败: %w", err)
+	}
+	maxOpenConns := c.MaxOpenConns
+	switch {
+	case maxOpenConns != 0:
+	case c.driver() == DriverSQLite:
+		// SQLite 同时只能有一个写入，且 :memory: 的每个连接都是独立的数据库
+		maxOpenConns = 1
+	default:
+		maxOpenConns = DefaultMaxOpenConns
 	}
-	sqlDB.SetMaxOpenConns(100) // 设置数据库连接池最大连接数
-	sqlDB.SetMaxIdleConns(20)  // 连接池最大允许的空闲连接数，如果没有sql任务需要执行的连接数大于20，超过的连接会被连接池关闭。
-	// 设置每个链接的过期时间
-	sqlDB.SetConnMaxLifetime(time.Second * 5)
-	err = sqlDB.Ping()
+	maxIdleConns := c.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = min(DefaultMaxIdleConns, maxOpenConns)
+	}
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+	sqlDB.SetConnMaxLifetime(c.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(c.ConnMaxIdleTime)
+
+	ctx := context.Background()
+	if c.PingTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.PingTimeout)
+		defer cancel()
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+	return gdb, nil
+}
+
+// Init
+//
+//	@Description: 按配置连接数据库，成功后设置为 GetDB 返回的全局 db
+//	@param c
+//	@return *gorm.DB
+//	@return error
+func Init(c *Config) (*gorm.DB, error) {
+	gdb, err := Open(c)
+	if err != nil {
+		return nil, err
+	}
+	db = gdb
+	return gdb, nil
+}
+
+// InitDB
+//
+//	@Description: 连接 MySQL 并设置为全局 db，失败时退出进程
+//	@param DBUser
+//	@param DBPwd
+//	@param DBHost
+//	@param DBPort
+//	@param DBName
+//
+// Deprecated: 使用 Init，失败时返回 error
+func InitDB(user, pwd, host, port, name string, l logger.Interface) {
+	// 与之前的 tcp(:port) 一致，host 为空时连接本机
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	_, err := Init(&Config{
+		User:            user,
+		Password:        pwd,
+		Host:            host,
+		Port:            port,
+		Name:            name,
+		ConnMaxLifetime: time.Second * 5,
+		Logger:          l,
+	})
 	if err != nil {
 		log.Println(err)
 		os.Exit(1)
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigDSN(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{
+			name: "default",
+			cfg:  Config{User: "root", Password: "p@ss", Host: "127.0.0.1", Port: "3306", Name: "app"},
+			want: "root:p@ss@tcp(127.0.0.1:3306)/app?loc=Local&parseTime=true&charset=utf8mb4",
+		},
+		{
+			name: "no password",
+			cfg:  Config{User: "root", Host: "db", Name: "app"},
+			want: "root@tcp(db)/app?loc=Local&parseTime=true&charset=utf8mb4",
+		},
+		{
+			name: "options",
+			cfg: Config{
+				User: "root", Host: "db", Port: "3307", Name: "app",
+				Charset: "utf8", Timezone: "Asia/Shanghai", TLS: "skip-verify",
+				Params: map[string]string{"sql_mode": "'TRADITIONAL'"},
+			},
+			want: "root@tcp(db:3307)/app?loc=Asia%2FShanghai&parseTime=true&tls=skip-verify&charset=utf8&sql_mode=%27TRADITIONAL%27",
+		},
+		{
+			name: "postgres",
+			cfg: Config{
+				Driver: DriverPostgres, User: "pg", Password: "p@ss", Host: "db", Port: "5432", Name: "app",
+				Timezone: "Asia/Shanghai", TLS: "skip-verify", Params: map[string]string{"application_name": "toolkit"},
+			},
+			want: "postgres://pg:p%40ss@db:5432/app?TimeZone=Asia%2FShanghai&application_name=toolkit&sslmode=require",
+		},
+		{
+			name: "postgres sslmode",
+			cfg:  Config{Driver: DriverPostgres, User: "pg", Host: "db", Name: "app", TLS: "verify-ca"},
+			want: "postgres://pg@db/app?sslmode=verify-ca",
+		},
+		{
+			name: "sqlite",
+			cfg:  Config{Driver: DriverSQLite, Name: "/var/lib/app.db", Params: map[string]string{"_pragma": "foreign_keys(1)"}},
+			want: "/var/lib/app.db?_pragma=foreign_keys%281%29",
+		},
+		{
+			name: "sqlite memory",
+			cfg:  Config{Driver: DriverSQLite, Name: ":memory:"},
+			want: ":memory:",
+		},
+		{
+			name: "raw",
+			cfg:  Config{DSN: "user@unix(/tmp/mysql.sock)/app", Host: "ignored"},
+			want: "user@unix(/tmp/mysql.sock)/app",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.cfg.dsn()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("dsn = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestConfigDSNError(t *testing.T) {
+	if _, err := (&Config{}).dsn(); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := (&Config{Host: "db", Timezone: "Nowhere/City"}).dsn(); err == nil {
+		t.Error("invalid timezone should fail")
+	}
+	if _, err := (&Config{Driver: DriverSQLite}).dsn(); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := (&Config{Driver: "oracle", Host: "db"}).dsn(); !errors.Is(err, ErrUnknownDriver) {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := Open(nil); !errors.Is(err, ErrNoDatabase) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	type user struct {
+		ID   uint
+		Name string
+	}
+	gdb, err := Open(&Config{Driver: DriverSQLite, Name: filepath.Join(t.TempDir(), "app.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.AutoMigrate(&user{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := gdb.Create(&user{Name: "alice"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var got user
+	if err := gdb.First(&got, "name = ?", "alice").Error; err != nil || got.ID == 0 {
+		t.Errorf("user = %+v, err = %v", got, err)
+	}
+	sqlDB, _ := gdb.DB()
+	if stats := sqlDB.Stats(); stats.MaxOpenConnections != 1 {
+		t.Errorf("max open conns = %d", stats.MaxOpenConnections)
+	}
+	_ = sqlDB.Close()
+}
+
+func TestConfigDSNTLSConfig(t *testing.T) {
+	first, err := (&Config{User: "root", Host: "db", Name: "app", TLSConfig: &tls.Config{ServerName: "a"}}).dsn() //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := (&Config{User: "root", Host: "db", Name: "app", TLSConfig: &tls.Config{ServerName: "b"}}).dsn() //nolint:gosec
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(first, "tls="+tlsConfigPrefix+"-") || first == second {
+		t.Errorf("dsn = %q, %q", first, second)
+	}
+
+	// 同一个配置多次 Open 复用同一个名称，不会重复注册
+	config := &tls.Config{ServerName: "c"} //nolint:gosec
+	c := &Config{User: "root", Host: "db", Name: "app", TLSConfig: config}
+	third, err := c.dsn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := c.dsn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third != again {
+		t.Errorf("dsn = %q, %q", third, again)
+	}
+}
+
+func TestOpenPingTimeout(t *testing.T) {
+	// 接受连接但不发送任何数据的服务端
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	start := time.Now()
+	_, err = Open(&Config{User: "root", Host: host, Port: port, Name: "app", PingTimeout: 200 * time.Millisecond})
+	if err == nil {
+		t.Fatal("open should fail")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("open took %s", elapsed)
+	}
+}
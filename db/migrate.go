@@ -0,0 +1,472 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"maps"
+	"math"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidMigration  = errors.New("迁移文件名格式错误")
+	ErrDuplicateVersion  = errors.New("迁移版本重复")
+	ErrMigrationNotFound = errors.New("迁移版本不存在")
+	ErrNoDownMigration   = errors.New("迁移没有 down 文件")
+	ErrLockTimeout       = errors.New("获取迁移锁超时")
+)
+
+const (
+	// DefaultMigrationTable 记录已执行迁移的表名
+	DefaultMigrationTable = "schema_migrations"
+	// DefaultLockTimeout 获取迁移锁的默认超时时间
+	DefaultLockTimeout = time.Minute
+)
+
+// migrationFileRegexp 迁移文件名，例如 0001_create_users.up.sql、0001_create_users.down.sql
+var migrationFileRegexp = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration 一个版本的迁移
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string // 为空时不能回滚
+}
+
+// MigrationStatus 一个版本的迁移状态
+type MigrationStatus struct {
+	Version   uint64    `json:"version"`
+	Name      string    `json:"name"`
+	Applied   bool      `json:"applied"`
+	AppliedAt time.Time `json:"appliedAt,omitzero"`
+	Missing   bool      `json:"missing,omitempty"` // 已执行但迁移文件已不存在
+}
+
+// schemaMigration 迁移表的一行
+type schemaMigration struct {
+	Version   uint64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// Migrator 按版本执行 SQL 迁移文件，已执行的版本记录在迁移表中
+type Migrator struct {
+	db          *gorm.DB
+	migrations  []Migration
+	table       string
+	lockTimeout time.Duration
+}
+
+// NewMigrator
+//
+//	@Description: 读取 dir 下的迁移文件，文件名格式为 <版本>_<名称>.up.sql 和 <版本>_<名称>.down.sql，其他文件会被忽略
+//	MySQL 的迁移文件包含多条 SQL 时，需在 Config.Params 中设置 multiStatements=true
+//	MySQL 的 DDL 会隐式提交事务，迁移失败时已执行的 DDL 不会回滚，需手动修复后再执行；PostgreSQL 和 SQLite 的 DDL 可以回滚
+//	@param gdb
+//	@param fsys 一般为 embed.FS 或 embed.Embed 的 EmbedData
+//	@param dir
+//	@return *Migrator
+//	@return error
+func NewMigrator(gdb *gorm.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := readMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:          gdb,
+		migrations:  migrations,
+		table:       DefaultMigrationTable,
+		lockTimeout: DefaultLockTimeout,
+	}, nil
+}
+
+// WithTable
+//
+//	@Description: 设置记录已执行迁移的表名
+//	@receiver m
+//	@param table
+//	@return *Migrator
+func (m *Migrator) WithTable(table string) *Migrator {
+	m.table = table
+	return m
+}
+
+// WithLockTimeout
+//
+//	@Description: 设置获取迁移锁的超时时间
+//	@receiver m
+//	@param timeout
+//	@return *Migrator
+func (m *Migrator) WithLockTimeout(timeout time.Duration) *Migrator {
+	m.lockTimeout = timeout
+	return m
+}
+
+// Migrations
+//
+//	@Description: 按版本排序的所有迁移
+//	@receiver m
+//	@return []Migration
+func (m *Migrator) Migrations() []Migration {
+	return slices.Clone(m.migrations)
+}
+
+// readMigrations 读取并按版本排序迁移文件
+func readMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+	byVersion := map[uint64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := migrationFileRegexp.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigration, entry.Name())
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMigration, entry.Name())
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: %d", ErrDuplicateVersion, version)
+		}
+		content := &migration.Up
+		if match[3] == "down" {
+			content = &migration.Down
+		}
+		if *content != "" {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateVersion, entry.Name())
+		}
+		*content = string(data)
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("%w: %d_%s 缺少 up 文件", ErrInvalidMigration, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// Up
+//
+//	@Description: 执行所有未执行的迁移
+//	@receiver m
+//	@param ctx
+//	@return error
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(tx *gorm.DB, applied map[uint64]schemaMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(tx, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down
+//
+//	@Description: 回滚最后执行的一个迁移，没有已执行的迁移时不做任何操作
+//	@receiver m
+//	@param ctx
+//	@return error
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(tx *gorm.DB, applied map[uint64]schemaMigration) error {
+		if len(applied) == 0 {
+			return nil
+		}
+		version := slices.Max(slices.Collect(maps.Keys(applied)))
+		migration, ok := m.find(version)
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrMigrationNotFound, version)
+		}
+		return m.rollback(tx, migration)
+	})
+}
+
+// To
+//
+//	@Description: 迁移到指定版本，执行不大于 version 的未执行迁移，回滚大于 version 的已执行迁移
+//	@receiver m
+//	@param ctx
+//	@param version 为 0 时回滚所有迁移
+//	@return error
+func (m *Migrator) To(ctx context.Context, version uint64) error {
+	if _, ok := m.find(version); !ok && version != 0 {
+		return fmt.Errorf("%w: %d", ErrMigrationNotFound, version)
+	}
+	return m.withLock(ctx, func(tx *gorm.DB, applied map[uint64]schemaMigration) error {
+		// 先从新到旧回滚
+		for _, v := range slices.Backward(slices.Sorted(maps.Keys(applied))) {
+			if v <= version {
+				break
+			}
+			migration, ok := m.find(v)
+			if !ok {
+				return fmt.Errorf("%w: %d", ErrMigrationNotFound, v)
+			}
+			if err := m.rollback(tx, migration); err != nil {
+				return err
+			}
+		}
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(tx, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status
+//
+//	@Description: 所有迁移的状态，按版本排序，包括已执行但文件已不存在的迁移
+//	@receiver m
+//	@param ctx
+//	@return []MigrationStatus
+//	@return error
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	tx := m.db.WithContext(ctx)
+	if err := m.createTable(tx); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(tx)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:   row.Version,
+			Name:      row.Name,
+			Applied:   true,
+			AppliedAt: row.AppliedAt,
+			Missing:   true,
+		})
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return statuses, nil
+}
+
+// find 按版本查找迁移
+func (m *Migrator) find(version uint64) (Migration, bool) {
+	i, ok := slices.BinarySearchFunc(m.migrations, version, func(migration Migration, v uint64) int {
+		return cmp.Compare(migration.Version, v)
+	})
+	if !ok {
+		return Migration{}, false
+	}
+	return m.migrations[i], true
+}
+
+// apply 在事务中执行迁移并记录版本，MySQL 的 DDL 会隐式提交，失败时不会回滚
+func (m *Migrator) apply(db *gorm.DB, migration Migration) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			return err //nolint:wrapcheck
+		}
+		return tx.Table(m.table).Create(&schemaMigration{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: time.Now(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// rollback 在事务中回滚迁移并删除版本记录
+func (m *Migrator) rollback(db *gorm.DB, migration Migration) error {
+	if migration.Down == "" {
+		return fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(migration.Down).Error; err != nil {
+			return err //nolint:wrapcheck
+		}
+		return tx.Table(m.table).Where("version = ?", migration.Version).Delete(&schemaMigration{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("回滚迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// withLock
+//
+//	@Description: 获取迁移锁后，以已执行的版本调用 fn
+//	加锁、查询和迁移都使用同一个连接，连接池的 MaxOpenConns 为 1 时也不会因等待第二个连接而死锁
+//	@receiver m
+//	@param ctx
+//	@param fn tx 为加锁的连接
+//	@return error
+func (m *Migrator) withLock(ctx context.Context, fn func(tx *gorm.DB, applied map[uint64]schemaMigration) error) error {
+	return m.db.WithContext(ctx).Connection(func(tx *gorm.DB) error { //nolint:wrapcheck
+		unlock, err := m.lock(ctx, tx)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+
+		if err := m.createTable(tx); err != nil {
+			return err
+		}
+		applied, err := m.applied(tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, applied)
+	})
+}
+
+// createTable 创建迁移表
+func (m *Migrator) createTable(db *gorm.DB) error {
+	err := db.Exec(
+		"CREATE TABLE IF NOT EXISTS ? (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at TIMESTAMP NOT NULL)",
+		clause.Table{Name: m.table},
+	).Error
+	if err != nil {
+		return fmt.Errorf("创建迁移表失败: %w", err)
+	}
+	return nil
+}
+
+// applied 已执行的版本
+func (m *Migrator) applied(db *gorm.DB) (map[uint64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Table(m.table).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("查询已执行的迁移失败: %w", err)
+	}
+	applied := make(map[uint64]schemaMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// lock
+//
+//	@Description: 在 tx 的连接上获取数据库的 advisory lock，避免多个实例同时迁移；SQLite 依赖数据库文件锁，不额外加锁
+//	@receiver m
+//	@param ctx
+//	@param tx 需固定一个连接，advisory lock 属于连接，加锁和解锁需使用同一个连接
+//	@return func() 释放锁
+//	@return error
+func (m *Migrator) lock(ctx context.Context, tx *gorm.DB) (func(), error) {
+	var lockSQL, unlockSQL string
+	var key any
+	var lockArgs []any
+	retry := false
+	switch m.db.Dialector.Name() {
+	case string(DriverMySQL):
+		// GET_LOCK 自身支持等待，超时返回 0；超时时间单位为秒，向上取整，避免不足 1s 时不等待
+		key = m.table
+		lockSQL, unlockSQL, lockArgs = "SELECT GET_LOCK(?, ?)", "SELECT RELEASE_LOCK(?)", []any{key, lockSeconds(m.lockTimeout)}
+	case string(DriverPostgres):
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(m.table))
+		key = int64(h.Sum64()) //nolint:gosec
+		lockSQL, unlockSQL, lockArgs = "SELECT pg_try_advisory_lock(?)", "SELECT pg_advisory_unlock(?)", []any{key}
+		retry = true
+	default:
+		return func() {}, nil
+	}
+
+	if err := m.tryLock(ctx, tx, retry, lockSQL, lockArgs...); err != nil {
+		return nil, err
+	}
+	return func() {
+		// ctx 可能已取消，解锁不使用 ctx
+		_ = tx.WithContext(context.Background()).Exec(unlockSQL, key).Error
+	}, nil
+}
+
+// lockSeconds GET_LOCK 的超时秒数，向上取整且至少为 1
+func lockSeconds(timeout time.Duration) int {
+	return max(1, int(math.Ceil(timeout.Seconds())))
+}
+
+// tryLock
+//
+//	@Description: 获取锁，retry 为 true 时在 lockTimeout 内每秒重试一次
+//	@receiver m
+//	@param ctx
+//	@param tx
+//	@param retry
+//	@param lockSQL 返回是否获取成功
+//	@param args
+//	@return error
+func (m *Migrator) tryLock(ctx context.Context, tx *gorm.DB, retry bool, lockSQL string, args ...any) error {
+	deadline := time.Now().Add(m.lockTimeout)
+	for {
+		var locked sql.NullBool
+		if err := tx.Raw(lockSQL, args...).Row().Scan(&locked); err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if locked.Bool {
+			return nil
+		}
+		if !retry || time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("获取迁移锁失败: %w", ctx.Err())
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed testdata/migrations
+var migrations embed.FS
+
+func newTestMigrator(t *testing.T) (*Migrator, *gorm.DB) {
+	t.Helper()
+	gdb, err := Open(&Config{Driver: DriverSQLite, Name: filepath.Join(t.TempDir(), "app.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB, _ := gdb.DB()
+		_ = sqlDB.Close()
+	})
+	m, err := NewMigrator(gdb, migrations, "testdata/migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, gdb
+}
+
+// appliedVersions 已执行的版本
+func appliedVersions(t *testing.T, m *Migrator) []uint64 {
+	t.Helper()
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []uint64
+	for _, status := range statuses {
+		if status.Applied {
+			versions = append(versions, status.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	m, gdb := newTestMigrator(t)
+	if len(m.Migrations()) != 3 {
+		t.Fatalf("migrations = %+v", m.Migrations())
+	}
+	if versions := appliedVersions(t, m); len(versions) != 0 {
+		t.Errorf("applied = %v", versions)
+	}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 3 {
+		t.Errorf("applied = %v", versions)
+	}
+	if !gdb.Migrator().HasColumn("users", "email") || !gdb.Migrator().HasTable("posts") {
+		t.Error("schema not migrated")
+	}
+	// 重复执行不做任何操作
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Down(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 2 || gdb.Migrator().HasTable("posts") {
+		t.Errorf("applied after down = %v", versions)
+	}
+
+	if err := m.To(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 1 || gdb.Migrator().HasColumn("users", "email") {
+		t.Errorf("applied after to 1 = %v", versions)
+	}
+	if err := m.To(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 3 {
+		t.Errorf("applied after to 3 = %v", versions)
+	}
+	if err := m.To(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if versions := appliedVersions(t, m); len(versions) != 0 || gdb.Migrator().HasTable("users") {
+		t.Errorf("applied after to 0 = %v", versions)
+	}
+	if err := m.To(ctx, 4); !errors.Is(err, ErrMigrationNotFound) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestMigratorStatusMissing(t *testing.T) {
+	ctx := context.Background()
+	m, gdb := newTestMigrator(t)
+	if err := m.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// 只保留第一个迁移文件
+	fsys := fstest.MapFS{
+		"0001_create_users.up.sql": {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);")},
+	}
+	m2, err := NewMigrator(gdb, fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m2.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 3 || statuses[0].Missing || !statuses[2].Missing || statuses[2].Name != "create_posts" || statuses[2].AppliedAt.IsZero() {
+		t.Errorf("statuses = %+v", statuses)
+	}
+	if err := m2.Down(ctx); !errors.Is(err, ErrMigrationNotFound) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	ctx := context.Background()
+	gdb, err := Open(&Config{Driver: DriverSQLite, Name: filepath.Join(t.TempDir(), "app.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"0002_broken.up.sql": {Data: []byte("CREATE TABLE b (id INTEGER); NOT SQL;")},
+	}
+	m, err := NewMigrator(gdb, fsys, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(ctx); err == nil {
+		t.Fatal("broken migration should fail")
+	}
+	if versions := appliedVersions(t, m); len(versions) != 1 || versions[0] != 1 || gdb.Migrator().HasTable("b") {
+		t.Errorf("applied = %v", versions)
+	}
+	if err := m.To(ctx, 0); !errors.Is(err, ErrNoDownMigration) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestReadMigrationsError(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"bad name":  {"create_users.up.sql": {}},
+		"zero":      {"0_init.up.sql": {}},
+		"duplicate": {"1_a.up.sql": {Data: []byte("x")}, "1_b.up.sql": {Data: []byte("y")}},
+		"down only": {"1_a.down.sql": {Data: []byte("x")}},
+	} {
+		if _, err := readMigrations(fsys, "."); err == nil {
+			t.Errorf("%s: err = nil", name)
+		}
+	}
+}
+
+func TestLockSeconds(t *testing.T) {
+	for timeout, want := range map[time.Duration]int{
+		0:                       1,
+		500 * time.Millisecond:  1,
+		time.Second:             1,
+		1500 * time.Millisecond: 2,
+		time.Minute:             60,
+	} {
+		if got := lockSeconds(timeout); got != want {
+			t.Errorf("lockSeconds(%s) = %d, want %d", timeout, got, want)
+		}
+	}
+}
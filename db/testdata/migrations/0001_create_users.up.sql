@@ -0,0 +1 @@
+CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(64) NOT NULL);
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN email VARCHAR(128);
+CREATE INDEX idx_users_email ON users (email);
@@ -0,0 +1 @@
+CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL);
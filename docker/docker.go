@@ -32,6 +32,7 @@ import (
 	"github.com/docker/docker/libnetwork/ipamapi"
 	"github.com/docker/docker/registry"
 	"github.com/docker/go-connections/nat"
+	"github.com/youcd/toolkit/bar"
 	"github.com/youcd/toolkit/log"
 	"github.com/youcd/toolkit/net"
 )
@@ -582,6 +583,30 @@ func (d *Docker) ImageLoadFromFile(ctx context.Context, imagePath string) error
 	return d.imageLoadFromIOReader(ctx, file)
 }
 
+// ImageLoadFromFileWithProgress
+//
+//	@Description: docker load，并通过 progress 展示读取镜像文件的进度
+//	@receiver d
+//	@param ctx
+//	@param imagePath
+//	@param progress
+//	@return error
+func (d *Docker) ImageLoadFromFileWithProgress(ctx context.Context, imagePath string, progress *bar.Progress) error {
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("加载镜像 error: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("加载镜像 error: %w", err)
+	}
+	progress.SetTotal(stat.Size())
+	return d.imageLoadFromIOReader(ctx, progress.Reader(file))
+}
+
 // ImagePull
 //
 //	@Description: 镜像拉取
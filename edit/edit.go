@@ -8,6 +8,8 @@ import (
 	"io/fs"
 	"os"
 	"os/exec"
+
+	"github.com/youcd/toolkit/bash"
 )
 
 const DefaultEditor = "vi"
@@ -19,6 +21,7 @@ type configData struct {
 type ConfigEdit struct {
 	configData configData
 	filePath   string
+	executor   bash.Executor
 }
 
 func NewConfigEdit(filePath string) (*ConfigEdit, error) {
@@ -41,6 +44,17 @@ func NewConfigEdit(filePath string) (*ConfigEdit, error) {
 	return c, nil
 }
 
+// WithExecutor
+//
+//	@Description: 通过执行器非交互地调用编辑器，用于单元测试或脚本化编辑
+//	@receiver c
+//	@param executor
+//	@return *ConfigEdit
+func (c *ConfigEdit) WithExecutor(executor bash.Executor) *ConfigEdit {
+	c.executor = executor
+	return c
+}
+
 type verifyFunc func(filename string) error
 
 // EditConfig
@@ -108,6 +122,14 @@ func (c ConfigEdit) openFileInEditor(ctx context.Context, filename string) error
 		editor = DefaultEditor
 	}
 
+	if c.executor != nil {
+		result := c.executor.Run(ctx, bash.NewArgvCommand(editor, filename))
+		if result.Err != nil {
+			return fmt.Errorf("editor %s: %w", editor, result.Err)
+		}
+		return nil
+	}
+
 	// 判断外部命令是否存在
 	executable, err := exec.LookPath(editor)
 	if err != nil {
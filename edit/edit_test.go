@@ -0,0 +1,53 @@
+package edit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/youcd/toolkit/bash"
+)
+
+func TestConfigEdit_EditConfig(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(configFile, []byte("port: 80\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("EDITOR", "sed")
+
+	c, err := NewConfigEdit(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 本机执行器下 sed 没有参数会失败，说明编辑器确实通过执行器调用
+	err = c.WithExecutor(bash.NewLocal()).EditConfig(context.Background(), nil)
+	if err == nil {
+		t.Fatal("EditConfig() should fail")
+	}
+
+	fake := bash.NewFake(bash.Fixture{Pattern: `^sed .*\.yaml$`})
+	err = c.WithExecutor(fake).EditConfig(context.Background(), func(filename string) error {
+		// 模拟编辑器修改临时文件
+		return os.WriteFile(filename, []byte("port: 8080\n"), 0o600)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || !strings.HasPrefix(calls[0], "sed ") {
+		t.Errorf("calls = %v", calls)
+	}
+	data, _ := os.ReadFile(configFile)
+	if string(data) != "port: 8080\n" {
+		t.Errorf("config = %s", data)
+	}
+
+	errVerify := errors.New("verify failed")
+	err = c.EditConfig(context.Background(), func(string) error { return errVerify })
+	if !errors.Is(err, errVerify) {
+		t.Errorf("EditConfig() err = %v", err)
+	}
+}
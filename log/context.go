@@ -0,0 +1,184 @@
+package log
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ctxKey log 包的 context key，避免与其他包的 key 冲突
+type ctxKey int
+
+const (
+	requestIDKey ctxKey = iota
+	fieldsKey
+)
+
+// 常用的 context 字段名
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+	FieldUser      = "user"
+	FieldTenant    = "tenant"
+)
+
+// RequestIDHeader 传递 request_id 的 HTTP 头
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen 客户端传入的 request_id 的最大长度，超出时重新生成
+const maxRequestIDLen = 128
+
+// traceparentHeader W3C Trace Context 的 HTTP 头
+const traceparentHeader = "traceparent"
+
+// field 一个 context 字段
+type field struct {
+	key   string
+	value any
+}
+
+// WithRequestID
+//
+//	@Description: 在 ctx 中设置 request_id
+//	@param ctx 为 nil 时使用 context.Background()
+//	@param requestID
+//	@return context.Context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID
+//
+//	@Description: 获取 ctx 中的 request_id，不存在时返回空字符串
+//	@param ctx
+//	@return string
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if requestID, ok := ctx.Value(requestIDKey).(string); ok {
+		return requestID
+	}
+	// 兼容直接使用字符串 key 设置的 request_id
+	if requestID, ok := ctx.Value(FieldRequestID).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// WithField
+//
+//	@Description: 在 ctx 中添加一个字段，WithCtx 和 GORM 日志会输出该字段，同名字段会被覆盖
+//	@param ctx 为 nil 时使用 context.Background()
+//	@param key 例如 FieldUser、FieldTenant
+//	@param value
+//	@return context.Context
+func WithField(ctx context.Context, key string, value any) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	old, _ := ctx.Value(fieldsKey).([]field)
+	fields := make([]field, 0, len(old)+1)
+	for _, f := range old {
+		if f.key != key {
+			fields = append(fields, f)
+		}
+	}
+	fields = append(fields, field{key: key, value: value})
+	return context.WithValue(ctx, fieldsKey, fields)
+}
+
+// WithTrace
+//
+//	@Description: 在 ctx 中设置 trace_id 和 span_id
+//	@param ctx
+//	@param traceID
+//	@param spanID 为空时不设置
+//	@return context.Context
+func WithTrace(ctx context.Context, traceID, spanID string) context.Context {
+	ctx = WithField(ctx, FieldTraceID, traceID)
+	if spanID != "" {
+		ctx = WithField(ctx, FieldSpanID, spanID)
+	}
+	return ctx
+}
+
+// Fields
+//
+//	@Description: ctx 中的所有字段，格式为 key、value 交替，request_id 在最前面，可直接传给 SugaredLogger.With
+//	@param ctx
+//	@return []any
+func Fields(ctx context.Context) []any {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).([]field)
+	kv := make([]any, 0, len(fields)*2+2)
+	if requestID := RequestID(ctx); requestID != "" {
+		kv = append(kv, FieldRequestID, requestID)
+	}
+	for _, f := range fields {
+		kv = append(kv, f.key, f.value)
+	}
+	return kv
+}
+
+// Middleware
+//
+//	@Description: HTTP 中间件，从 X-Request-ID 读取 request_id，不存在或格式不合法时生成，并写入响应头；从 traceparent 读取 trace_id、span_id
+//	@param next
+//	@return http.Handler
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		ctx := WithRequestID(r.Context(), requestID)
+		if traceID, spanID, ok := parseTraceparent(r.Header.Get(traceparentHeader)); ok {
+			ctx = WithTrace(ctx, traceID, spanID)
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID 客户端传入的 request_id 只允许字母、数字和 -_.:，长度不超过 maxRequestIDLen，避免日志注入和过长的字段
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceparent
+//
+//	@Description: 解析 W3C traceparent，格式为 version-traceid-spanid-flags
+//	@param traceparent
+//	@return string trace_id
+//	@return string span_id
+//	@return bool
+func parseTraceparent(traceparent string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return "", "", false
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
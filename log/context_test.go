@@ -0,0 +1,104 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/natefinch/lumberjack"
+	ormogger "gorm.io/gorm/logger"
+)
+
+func TestFields(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithField(ctx, FieldUser, "admin")
+	ctx = WithTrace(ctx, "trace-1", "span-1")
+	ctx = WithField(ctx, FieldUser, "root")
+
+	got, _ := json.Marshal(Fields(ctx))
+	if string(got) != `["request_id","req-1","trace_id","trace-1","span_id","span-1","user","root"]` {
+		t.Errorf("fields = %s", got)
+	}
+	if RequestID(SetRequestId(context.Background())) == "" {
+		t.Error("SetRequestId should set a request_id")
+	}
+	if fields := Fields(WithField(nil, FieldUser, "admin")); len(fields) != 2 {
+		t.Errorf("fields = %v", fields)
+	}
+	if fields := Fields(context.Background()); len(fields) != 0 {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var ctx context.Context
+	handler := Middleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if RequestID(ctx) != "req-1" || rec.Header().Get(RequestIDHeader) != "req-1" {
+		t.Errorf("request_id = %q, header = %q", RequestID(ctx), rec.Header().Get(RequestIDHeader))
+	}
+	fields, _ := json.Marshal(Fields(ctx))
+	if string(fields) != `["request_id","req-1","trace_id","4bf92f3577b34da6a3ce929d0e0e4736","span_id","00f067aa0ba902b7"]` {
+		t.Errorf("fields = %s", fields)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if RequestID(ctx) == "" || rec.Header().Get(RequestIDHeader) != RequestID(ctx) {
+		t.Errorf("generated request_id = %q, header = %q", RequestID(ctx), rec.Header().Get(RequestIDHeader))
+	}
+
+	for _, invalid := range []string{"req-1\nfake log", strings.Repeat("a", maxRequestIDLen+1)} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if RequestID(ctx) == invalid || len(RequestID(ctx)) != 36 {
+			t.Errorf("request_id = %q, want regenerated", RequestID(ctx))
+		}
+	}
+}
+
+func TestCtxFieldsInLogs(t *testing.T) {
+	defer Init(nil)
+	file := filepath.Join(t.TempDir(), "app.log")
+	Init(&Config{
+		LumberjackCfg: &lumberjack.Logger{Filename: file},
+		FileEncoder:   &EncoderConfig{Encoding: EncodingJSON},
+	})
+	ctx := WithTrace(WithField(WithRequestID(context.Background(), "req-1"), FieldTenant, "t1"), "trace-1", "span-1")
+	WithCtx(ctx).Info("hello")
+	gormLogger := &ZapGormLogger{LogLevel: ormogger.Info}
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("lines = %q", lines)
+	}
+	for _, line := range lines {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry[FieldRequestID] != "req-1" || entry[FieldTenant] != "t1" || entry[FieldTraceID] != "trace-1" || entry[FieldSpanID] != "span-1" {
+			t.Errorf("entry = %v", entry)
+		}
+	}
+}
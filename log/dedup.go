@@ -0,0 +1,194 @@
+package log
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldRepeated 去重后输出的重复次数字段
+const FieldRepeated = "repeated"
+
+// dedupMaxKeys 去重记录的最大数量，超出后不再去重新的日志，避免内存无限增长
+const dedupMaxKeys = 4096
+
+// SamplingConfig 采样配置，与 zap 的采样相同：每个 Tick 内相同级别和 msg 的日志输出前 First 条，之后每 Thereafter 条输出一条
+type SamplingConfig struct {
+	Tick       time.Duration // 为空时为 1s
+	First      int
+	Thereafter int
+}
+
+// dedupKey 级别、logger 名称、msg 以及 With 和调用时的字段都相同的日志视为重复
+type dedupKey struct {
+	level   zapcore.Level
+	logger  string
+	message string
+	fields  string // 编码后的所有字段
+}
+
+// dedupEntry 一条日志在窗口内的重复记录，timer 在窗口结束时输出汇总并删除记录
+type dedupEntry struct {
+	first  time.Time
+	count  int
+	entry  zapcore.Entry
+	fields []zapcore.Field
+	core   zapcore.Core
+	timer  *time.Timer
+}
+
+// dedupState 在 With 派生的 core 之间共享，With 的字段是 key 的一部分，字段不同的 core 互不影响
+type dedupState struct {
+	mux     sync.Mutex
+	entries map[dedupKey]*dedupEntry
+}
+
+// dedupCore 窗口内重复的日志只输出第一条，窗口结束后输出一条带 repeated 字段的汇总
+type dedupCore struct {
+	zapcore.Core
+	window time.Duration
+	state  *dedupState
+	enc    zapcore.Encoder // 已编码 With 的字段，用于生成 key
+}
+
+// newDedupCore
+//
+//	@Description: 包装 core，完全相同的日志在 window 内只输出一次
+//	@param core
+//	@param window
+//	@return zapcore.Core
+func newDedupCore(core zapcore.Core, window time.Duration) zapcore.Core {
+	return &dedupCore{
+		Core:   core,
+		window: window,
+		state:  &dedupState{entries: make(map[dedupKey]*dedupEntry)},
+		enc:    zapcore.NewJSONEncoder(zapcore.EncoderConfig{}),
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &dedupCore{Core: c.Core.With(fields), window: c.window, state: c.state, enc: enc}
+}
+
+// Check 字段在 Write 时才能拿到，去重在 Write 中进行
+func (c *dedupCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		return fmt.Errorf("编码日志字段失败: %w", err)
+	}
+	key := dedupKey{level: entry.Level, logger: entry.LoggerName, message: entry.Message, fields: buf.String()}
+	buf.Free()
+
+	// 只检查当前 key 的记录，过期的记录由各自的 timer 删除
+	var expired []*dedupEntry
+	c.state.mux.Lock()
+	record, ok := c.state.entries[key]
+	suppress := ok && entry.Time.Sub(record.first) < c.window
+	switch {
+	case suppress:
+		record.count++
+	case ok:
+		// 窗口已结束但 timer 还未触发
+		c.state.remove(key, record)
+		expired = append(expired, record)
+		c.state.add(key, entry, fields, c)
+	case len(c.state.entries) < dedupMaxKeys:
+		c.state.add(key, entry, fields, c)
+	}
+	c.state.mux.Unlock()
+
+	writeRepeated(expired, entry.Time)
+	if suppress {
+		return nil
+	}
+	// 经过被包装 core 的 Check，保证各输出的级别和采样生效
+	if ce := c.Core.Check(entry, nil); ce != nil {
+		ce.Write(fields...)
+	}
+	return nil
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.mux.Lock()
+	expired := make([]*dedupEntry, 0, len(c.state.entries))
+	for key, record := range c.state.entries {
+		c.state.remove(key, record)
+		expired = append(expired, record)
+	}
+	c.state.mux.Unlock()
+	writeRepeated(expired, time.Now())
+	return c.Core.Sync() //nolint:wrapcheck
+}
+
+// add 记录 key 的第一条日志，窗口结束时由 timer 输出汇总，调用方需持有锁
+func (s *dedupState) add(key dedupKey, entry zapcore.Entry, fields []zapcore.Field, c *dedupCore) {
+	record := &dedupEntry{first: entry.Time, entry: entry, fields: slices.Clone(fields), core: c.Core}
+	record.timer = time.AfterFunc(c.window, func() {
+		s.mux.Lock()
+		// 已被 Check 或 Sync 删除
+		if s.entries[key] != record {
+			s.mux.Unlock()
+			return
+		}
+		delete(s.entries, key)
+		s.mux.Unlock()
+		writeRepeated([]*dedupEntry{record}, time.Now())
+	})
+	s.entries[key] = record
+}
+
+// remove 删除记录并停止 timer，调用方需持有锁
+func (s *dedupState) remove(key dedupKey, record *dedupEntry) {
+	record.timer.Stop()
+	delete(s.entries, key)
+}
+
+// writeRepeated 输出有重复的记录的汇总，按各输出的级别过滤
+func writeRepeated(records []*dedupEntry, now time.Time) {
+	for _, record := range records {
+		if record.count == 0 {
+			continue
+		}
+		entry := record.entry
+		entry.Time = now
+		if ce := record.core.Check(entry, nil); ce != nil {
+			ce.Write(append(record.fields, zap.Int(FieldRepeated, record.count))...)
+		}
+	}
+}
+
+// wrapCore
+//
+//	@Description: 按配置为 core 增加采样和去重，去重在采样之前，保证重复次数准确
+//	@param core
+//	@param sampling
+//	@param dedup
+//	@return zapcore.Core
+func wrapCore(core zapcore.Core, sampling *SamplingConfig, dedup time.Duration) zapcore.Core {
+	if sampling != nil {
+		tick := sampling.Tick
+		if tick <= 0 {
+			tick = time.Second
+		}
+		core = zapcore.NewSamplerWithOptions(core, tick, sampling.First, sampling.Thereafter)
+	}
+	if dedup > 0 {
+		core = newDedupCore(core, dedup)
+	}
+	return core
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedup(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(100)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}, Dedup: 50 * time.Millisecond})
+	l := WithCtx(context.Background())
+	for range 5 {
+		l.Warn("reconnect failed")
+	}
+	l.Info("other")
+	time.Sleep(60 * time.Millisecond)
+	l.Warn("reconnect failed")
+	l.Info("flush")
+	_ = GetLogger().Sync()
+
+	var got []string
+	for _, entry := range ring.Entries() {
+		got = append(got, fmt.Sprintf("%s:%v", entry.Message, entry.Fields[FieldRepeated]))
+	}
+	want := "reconnect failed:<nil>,other:<nil>,reconnect failed:4,reconnect failed:<nil>,flush:<nil>"
+	if strings.Join(got, ",") != want {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(100)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}, Sampling: &SamplingConfig{Tick: time.Minute, First: 2, Thereafter: 5}})
+	for range 12 {
+		WithCtx(context.Background()).Info("spam")
+	}
+	// 前 2 条，之后第 5、10 条
+	if n := len(ring.Entries()); n != 4 {
+		t.Errorf("entries = %d, want 4", n)
+	}
+}
+
+func TestDedupFlushOnWindowEnd(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(100)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}, Dedup: 50 * time.Millisecond})
+	l := WithCtx(context.Background())
+	for range 3 {
+		l.Warn("reconnect failed")
+	}
+	// 没有后续日志和 Sync，汇总由窗口结束时的 timer 输出
+	time.Sleep(150 * time.Millisecond)
+
+	entries := ring.Entries()
+	if len(entries) != 2 || entries[1].Fields[FieldRepeated] != int64(2) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
+
+func TestDedupFields(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(100)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}, Dedup: time.Second})
+	// With 的字段或调用时的字段不同，都不是重复的日志
+	WithCtx(WithRequestID(context.Background(), "r1")).Infow("request done", "user", "alice")
+	WithCtx(WithRequestID(context.Background(), "r2")).Infow("request done", "user", "alice")
+	WithCtx(WithRequestID(context.Background(), "r2")).Infow("request done", "user", "bob")
+	WithCtx(WithRequestID(context.Background(), "r2")).Infow("request done", "user", "bob")
+	_ = GetLogger().Sync()
+
+	var got []string
+	for _, entry := range ring.Entries() {
+		got = append(got, fmt.Sprintf("%v/%v:%v", entry.Fields[FieldRequestID], entry.Fields["user"], entry.Fields[FieldRepeated]))
+	}
+	want := "r1/alice:<nil>,r2/alice:<nil>,r2/bob:<nil>,r2/bob:1"
+	if strings.Join(got, ",") != want {
+		t.Errorf("entries = %q, want %q", got, want)
+	}
+}
@@ -16,8 +16,20 @@ import (
 type ZapGormLogger struct {
 	SlowThreshold time.Duration
 	LogLevel      ormogger.LogLevel
+	Structured    bool // 以 sql、rows、elapsed_ms、caller、slow、error 字段输出，不带颜色
+	Redact        bool // SQL 中的参数不替换为实际值，保留为 ?
+	stats         *gormStats
 }
 
+// GORM 结构化日志的字段名
+const (
+	FieldSQL       = "sql"
+	FieldRows      = "rows"
+	FieldElapsedMs = "elapsed_ms"
+	FieldCaller    = "caller"
+	FieldSlow      = "slow"
+)
+
 func NewGormLogger(slowThreshold time.Duration, logLevel string) *ZapGormLogger {
 	SetLogLevel(logLevel)
 	var l ormogger.LogLevel
@@ -39,6 +51,36 @@ func NewGormLogger(slowThreshold time.Duration, logLevel string) *ZapGormLogger
 	}
 }
 
+// WithStructured
+//
+//	@Description: 设置是否以结构化字段输出 SQL
+//	@receiver l
+//	@param structured
+//	@return *ZapGormLogger
+func (l *ZapGormLogger) WithStructured(structured bool) *ZapGormLogger {
+	l.Structured = structured
+	return l
+}
+
+// WithRedact
+//
+//	@Description: 设置是否隐藏 SQL 中的参数
+//	@receiver l
+//	@param redact
+//	@return *ZapGormLogger
+func (l *ZapGormLogger) WithRedact(redact bool) *ZapGormLogger {
+	l.Redact = redact
+	return l
+}
+
+// ParamsFilter 实现 gorm.ParamsFilter，Redact 时不将参数替换到 SQL 中
+func (l *ZapGormLogger) ParamsFilter(_ context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.Redact {
+		return sql, nil
+	}
+	return sql, params
+}
+
 // LogMode 实现 LogMode
 func (l *ZapGormLogger) LogMode(level ormogger.LogLevel) ormogger.Interface {
 	switch level {
@@ -167,16 +209,20 @@ func colorizeDuration(duration time.Duration, threshold time.Duration) string {
 // Trace 实现 Trace，用于打印 SQL
 
 func (l *ZapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
-	if l.LogLevel <= ormogger.Silent {
+	if l.LogLevel <= ormogger.Silent && l.stats == nil {
 		return
 	}
 	callerFile, callerLine := getBusinessCaller()
 
 	elapsed := time.Since(begin)
 	sql, rows := fc()
-
-	coloredSQL := colorizeSQLType(sql)
-	coloredDuration := colorizeDuration(elapsed, l.SlowThreshold)
+	slow := elapsed > l.SlowThreshold && l.SlowThreshold != 0
+	if l.stats != nil {
+		l.stats.record(sql, fmt.Sprintf("%s:%d", callerFile, callerLine), elapsed, slow, err)
+	}
+	if l.LogLevel <= ormogger.Silent {
+		return
+	}
 
 	// 获取基础 logger
 	base := GetLogger()
@@ -186,28 +232,63 @@ func (l *ZapGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (s
 	}
 
 	// 创建一个不带 caller 的 logger，避免显示 GORM 内部堆栈
-	logLogger := base.Desugar().WithOptions(zap.WithCaller(false)).Sugar()
+	logLogger := base.Desugar().WithOptions(zap.WithCaller(false))
 
-	// 保留 request_id 上下文
-	if ctx != nil {
-		if requestId, ok := ctx.Value("request_id").(string); ok && requestId != "" {
-			logLogger = logLogger.With("request_id", requestId)
-		}
+	// 保留 request_id 等上下文字段，与 WithCtx 一致
+	if fields := Fields(ctx); len(fields) > 0 {
+		logLogger = logLogger.Sugar().With(fields...).Desugar()
+	}
+
+	if l.Structured {
+		l.traceStructured(logLogger, fmt.Sprintf("%s:%d", callerFile, callerLine), sql, rows, elapsed, slow, err)
+		return
 	}
 
+	coloredSQL := colorizeSQLType(sql)
+	coloredDuration := colorizeDuration(elapsed, l.SlowThreshold)
+
 	// 构建带颜色的消息
 	switch {
 	case err != nil && l.LogLevel >= ormogger.Error:
 		msg := fmt.Sprintf("\033[35mGORM\033[0m [ERROR] %s:%d | SQL: %s | rows: %d | elapsed: %s | error: %v",
 			callerFile, callerLine, coloredSQL, rows, coloredDuration, err)
-		logLogger.Desugar().Error(msg)
-	case elapsed > l.SlowThreshold && l.SlowThreshold != 0 && l.LogLevel >= ormogger.Warn:
+		logLogger.Error(msg)
+	case slow && l.LogLevel >= ormogger.Warn:
 		msg := fmt.Sprintf("\033[35mGORM\033[0m [SLOW] %s:%d | SQL: %s | rows: %d | elapsed: %s",
 			callerFile, callerLine, coloredSQL, rows, coloredDuration)
-		logLogger.Desugar().Warn(msg)
+		logLogger.Warn(msg)
 	case l.LogLevel >= ormogger.Info:
 		msg := fmt.Sprintf("\033[35mGORM\033[0m %s:%d | SQL: %s | rows: %d | elapsed: %s",
 			callerFile, callerLine, coloredSQL, rows, coloredDuration)
-		logLogger.Desugar().Info(msg)
+		logLogger.Info(msg)
+	}
+}
+
+// traceStructured
+//
+//	@Description: 以字段输出 SQL，级别的颜色由 console 编码器决定
+//	@receiver l
+//	@param logLogger
+//	@param caller 业务代码的调用位置
+//	@param sql
+//	@param rows
+//	@param elapsed
+//	@param slow
+//	@param err
+func (l *ZapGormLogger) traceStructured(logLogger *zap.Logger, caller, sql string, rows int64, elapsed time.Duration, slow bool, err error) {
+	fields := []zap.Field{
+		zap.String(FieldSQL, sql),
+		zap.Int64(FieldRows, rows),
+		zap.Float64(FieldElapsedMs, float64(elapsed.Microseconds())/1000),
+		zap.String(FieldCaller, caller),
+		zap.Bool(FieldSlow, slow),
+	}
+	switch {
+	case err != nil && l.LogLevel >= ormogger.Error:
+		logLogger.Error("gorm", append(fields, zap.Error(err))...)
+	case slow && l.LogLevel >= ormogger.Warn:
+		logLogger.Warn("gorm slow query", fields...)
+	case l.LogLevel >= ormogger.Info:
+		logLogger.Info("gorm", fields...)
 	}
 }
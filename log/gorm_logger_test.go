@@ -0,0 +1,68 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ormogger "gorm.io/gorm/logger"
+)
+
+func TestGormLoggerStructured(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(10)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}})
+
+	gormLogger := (&ZapGormLogger{LogLevel: ormogger.Info, SlowThreshold: time.Millisecond}).WithStructured(true)
+	ctx := WithRequestID(context.Background(), "req-1")
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	gormLogger.Trace(ctx, time.Now().Add(-time.Second), func() (string, int64) { return "SELECT 2", 2 }, nil)
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) { return "SELECT 3", 0 }, errors.New("boom"))
+
+	entries := ring.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("entries = %v", entries)
+	}
+	for i, want := range []struct {
+		level string
+		sql   string
+		slow  bool
+	}{{"info", "SELECT 1", false}, {"warn", "SELECT 2", true}, {"error", "SELECT 3", false}} {
+		entry := entries[i]
+		if entry.Level != want.level || entry.Fields[FieldSQL] != want.sql || entry.Fields[FieldSlow] != want.slow {
+			t.Errorf("entry %d = %v", i, entry)
+		}
+		if strings.Contains(entry.Message, "\033[") || strings.Contains(want.sql, "\033[") {
+			t.Errorf("entry %d has color codes: %q", i, entry.Message)
+		}
+		if entry.Fields[FieldRequestID] != "req-1" {
+			t.Errorf("entry %d request_id = %v", i, entry.Fields[FieldRequestID])
+		}
+		if caller, _ := entry.Fields[FieldCaller].(string); !strings.Contains(caller, "gorm_logger_test.go:") {
+			t.Errorf("entry %d caller = %q", i, caller)
+		}
+		if _, ok := entry.Fields[FieldElapsedMs].(float64); !ok {
+			t.Errorf("entry %d elapsed_ms = %v", i, entry.Fields[FieldElapsedMs])
+		}
+	}
+	if entries[1].Fields[FieldElapsedMs].(float64) < 1000 {
+		t.Errorf("elapsed_ms = %v", entries[1].Fields[FieldElapsedMs])
+	}
+	if entries[2].Fields["error"] != "boom" {
+		t.Errorf("error = %v", entries[2].Fields["error"])
+	}
+}
+
+func TestGormLoggerRedact(t *testing.T) {
+	gormLogger := &ZapGormLogger{}
+	sql, params := gormLogger.ParamsFilter(context.Background(), "SELECT * FROM users WHERE name = ?", "alice")
+	if sql != "SELECT * FROM users WHERE name = ?" || len(params) != 1 {
+		t.Errorf("sql = %q, params = %v", sql, params)
+	}
+	sql, params = gormLogger.WithRedact(true).ParamsFilter(context.Background(), "SELECT * FROM users WHERE name = ?", "alice")
+	if sql != "SELECT * FROM users WHERE name = ?" || params != nil {
+		t.Errorf("sql = %q, params = %v", sql, params)
+	}
+}
@@ -0,0 +1,283 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GormStatsPath StatsHandler 默认的挂载路径
+const GormStatsPath = "/debug/gorm/stats"
+
+const (
+	// statsMaxFingerprints 统计的 SQL 指纹的最大数量，超出后不再统计新的 SQL，避免内存无限增长
+	statsMaxFingerprints = 1000
+	// statsSamples 每个 SQL 指纹保留最近多少次耗时，用于计算分位数
+	statsSamples = 256
+)
+
+// QueryStat 一类 SQL 的统计
+type QueryStat struct {
+	Fingerprint string    `json:"fingerprint"` // 去掉参数后的 SQL
+	Count       int64     `json:"count"`
+	Errors      int64     `json:"errors"`
+	Slow        int64     `json:"slow"` // 超过 SlowThreshold 的次数
+	P50Ms       float64   `json:"p50Ms"`
+	P95Ms       float64   `json:"p95Ms"`
+	MaxMs       float64   `json:"maxMs"`
+	TotalMs     float64   `json:"totalMs"`
+	LastCaller  string    `json:"lastCaller"`
+	LastSeen    time.Time `json:"lastSeen"`
+}
+
+// queryStat 统计中的一类 SQL，samples 为最近 statsSamples 次的耗时
+type queryStat struct {
+	stat    QueryStat
+	samples []float64
+	next    int
+}
+
+// gormStats 按 SQL 指纹的统计
+type gormStats struct {
+	mux   sync.Mutex
+	stats map[string]*queryStat
+}
+
+var (
+	sqlStringRegexp = regexp.MustCompile(`'(?:[^'\\]|\\.|'')*'`)
+	sqlNumberRegexp = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlInListRegexp = regexp.MustCompile(`(?i)\bIN\s*\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlValuesRegexp = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)(?:\s*,\s*\(\s*\?(?:\s*,\s*\?)*\s*\))+`)
+	sqlSpacesRegexp = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint
+//
+//	@Description: 将 SQL 中的字符串、数字替换为 ?，IN 列表和多行 VALUES 合并为一个，用于将同一类 SQL 归为一组
+//	@param sql
+//	@return string
+func Fingerprint(sql string) string {
+	sql = sqlStringRegexp.ReplaceAllString(sql, "?")
+	sql = sqlNumberRegexp.ReplaceAllString(sql, "?")
+	sql = sqlSpacesRegexp.ReplaceAllString(strings.TrimSpace(sql), " ")
+	sql = sqlInListRegexp.ReplaceAllString(sql, "IN (?)")
+	sql = sqlValuesRegexp.ReplaceAllString(sql, "(?)")
+	return sql
+}
+
+// WithStats
+//
+//	@Description: 设置是否按 SQL 指纹统计次数、耗时和错误，与日志级别无关，Silent 时也会统计
+//	@receiver l
+//	@param enable
+//	@return *ZapGormLogger
+func (l *ZapGormLogger) WithStats(enable bool) *ZapGormLogger {
+	if !enable {
+		l.stats = nil
+		return l
+	}
+	if l.stats == nil {
+		l.stats = &gormStats{stats: make(map[string]*queryStat)}
+	}
+	return l
+}
+
+// record 记录一次 SQL
+func (s *gormStats) record(sql, caller string, elapsed time.Duration, slow bool, err error) {
+	fingerprint := Fingerprint(sql)
+	ms := float64(elapsed.Microseconds()) / 1000
+	now := time.Now()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	q, ok := s.stats[fingerprint]
+	if !ok {
+		if len(s.stats) >= statsMaxFingerprints {
+			return
+		}
+		q = &queryStat{stat: QueryStat{Fingerprint: fingerprint}}
+		s.stats[fingerprint] = q
+	}
+	q.stat.Count++
+	q.stat.TotalMs += ms
+	q.stat.MaxMs = max(q.stat.MaxMs, ms)
+	q.stat.LastCaller = caller
+	q.stat.LastSeen = now
+	if err != nil {
+		q.stat.Errors++
+	}
+	if slow {
+		q.stat.Slow++
+	}
+	if len(q.samples) < statsSamples {
+		q.samples = append(q.samples, ms)
+	} else {
+		q.samples[q.next] = ms
+		q.next = (q.next + 1) % statsSamples
+	}
+}
+
+// snapshot 返回计算分位数后的统计
+func (s *gormStats) snapshot() []QueryStat {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	stats := make([]QueryStat, 0, len(s.stats))
+	for _, q := range s.stats {
+		stat := q.stat
+		samples := slices.Clone(q.samples)
+		slices.Sort(samples)
+		stat.P50Ms = percentile(samples, 0.5)
+		stat.P95Ms = percentile(samples, 0.95)
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// percentile 已排序的 samples 的分位数
+func percentile(samples []float64, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	return samples[int(p*float64(len(samples)-1)+0.5)]
+}
+
+// StatsOrder QueryStats 的排序方式
+type StatsOrder string
+
+const (
+	OrderByTotal  StatsOrder = "total"
+	OrderByCount  StatsOrder = "count"
+	OrderByP95    StatsOrder = "p95"
+	OrderByMax    StatsOrder = "max"
+	OrderByErrors StatsOrder = "errors"
+)
+
+// ErrInvalidOrder 不支持的排序方式
+var ErrInvalidOrder = errors.New("无效的排序方式")
+
+// QueryStats
+//
+//	@Description: 返回 SQL 统计，未调用 WithStats(true) 时返回 nil
+//	@receiver l
+//	@param order 排序方式，从大到小，为空时按总耗时
+//	@param limit 最多返回多少条，小于 1 时返回全部
+//	@return []QueryStat
+//	@return error
+func (l *ZapGormLogger) QueryStats(order StatsOrder, limit int) ([]QueryStat, error) {
+	key, err := statsKey(order)
+	if err != nil {
+		return nil, err
+	}
+	if l.stats == nil {
+		return nil, nil
+	}
+	stats := l.stats.snapshot()
+	slices.SortFunc(stats, func(a, b QueryStat) int {
+		if c := -compareFloat(key(a), key(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, nil
+}
+
+// ResetStats
+//
+//	@Description: 清空 SQL 统计
+//	@receiver l
+func (l *ZapGormLogger) ResetStats() {
+	if l.stats == nil {
+		return
+	}
+	l.stats.mux.Lock()
+	defer l.stats.mux.Unlock()
+	l.stats.stats = make(map[string]*queryStat)
+}
+
+// statsKey 排序方式对应的排序字段
+func statsKey(order StatsOrder) (func(QueryStat) float64, error) {
+	switch order {
+	case OrderByTotal, "":
+		return func(s QueryStat) float64 { return s.TotalMs }, nil
+	case OrderByCount:
+		return func(s QueryStat) float64 { return float64(s.Count) }, nil
+	case OrderByP95:
+		return func(s QueryStat) float64 { return s.P95Ms }, nil
+	case OrderByMax:
+		return func(s QueryStat) float64 { return s.MaxMs }, nil
+	case OrderByErrors:
+		return func(s QueryStat) float64 { return float64(s.Errors) }, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOrder, order)
+	}
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// StatsHandler
+//
+//	@Description: 查看 SQL 统计的 http.Handler
+//	GET 返回 []QueryStat，query 参数 order 为 total、count、p95、max、errors，limit 为最多返回多少条；DELETE 清空统计
+//	@receiver l
+//	@return http.Handler
+func (l *ZapGormLogger) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			l.ResetStats()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var limit int
+		if value := r.URL.Query().Get("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil {
+				http.Error(w, fmt.Sprintf("limit 格式错误: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+		stats, err := l.QueryStats(StatsOrder(r.URL.Query().Get("order")), limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if stats == nil {
+			stats = []QueryStat{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stats)
+	})
+}
+
+// RegisterHandlers
+//
+//	@Description: 将 StatsHandler 注册到 GormStatsPath，用法与 pprof.RegisterHandlers 相同
+//	@receiver l
+//	@param h
+func (l *ZapGormLogger) RegisterHandlers(h func(pattern string, handler func(http.ResponseWriter, *http.Request))) {
+	h(GormStatsPath, l.StatsHandler().ServeHTTP)
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	ormogger "gorm.io/gorm/logger"
+)
+
+func TestFingerprint(t *testing.T) {
+	for sql, want := range map[string]string{
+		"SELECT * FROM `users` WHERE id = 1":                        "SELECT * FROM `users` WHERE id = ?",
+		"SELECT * FROM users WHERE name = 'it''s'  AND  age > 18.5": "SELECT * FROM users WHERE name = ? AND age > ?",
+		"SELECT * FROM users WHERE id IN (1, 2,3)":                  "SELECT * FROM users WHERE id IN (?)",
+		"INSERT INTO t1 (a,b) VALUES (1,'x'),(2,'y'),(3,'z')":       "INSERT INTO t1 (a,b) VALUES (?)",
+		"SELECT * FROM users WHERE name = ? LIMIT 10":               "SELECT * FROM users WHERE name = ? LIMIT ?",
+		"SELECT * FROM users WHERE id in (?,?)\n ORDER BY id":       "SELECT * FROM users WHERE id IN (?) ORDER BY id",
+	} {
+		if got := Fingerprint(sql); got != want {
+			t.Errorf("Fingerprint(%q) = %q, want %q", sql, got, want)
+		}
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	gormLogger := (&ZapGormLogger{LogLevel: ormogger.Silent, SlowThreshold: 55 * time.Millisecond}).WithStats(true)
+	ctx := context.Background()
+	for i := range 10 {
+		begin := time.Now().Add(-time.Duration(i+1) * 10 * time.Millisecond)
+		gormLogger.Trace(ctx, begin, func() (string, int64) { return "SELECT * FROM users WHERE id = " + string(rune('0'+i)), 1 }, nil)
+	}
+	gormLogger.Trace(ctx, time.Now(), func() (string, int64) { return "DELETE FROM users WHERE id = 1", 0 }, errors.New("boom"))
+
+	stats, err := gormLogger.QueryStats(OrderByTotal, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	users := stats[0]
+	if users.Fingerprint != "SELECT * FROM users WHERE id = ?" || users.Count != 10 || users.Errors != 0 || users.Slow != 5 {
+		t.Errorf("users = %+v", users)
+	}
+	if users.P50Ms < 50 || users.P50Ms > users.P95Ms || users.P95Ms > users.MaxMs || users.MaxMs < 100 {
+		t.Errorf("users latency = %+v", users)
+	}
+	if !strings.Contains(users.LastCaller, "gorm_stats_test.go:") {
+		t.Errorf("last caller = %q", users.LastCaller)
+	}
+
+	stats, _ = gormLogger.QueryStats(OrderByErrors, 1)
+	if len(stats) != 1 || stats[0].Errors != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if _, err := gormLogger.QueryStats("bad", 0); !errors.Is(err, ErrInvalidOrder) {
+		t.Errorf("err = %v", err)
+	}
+
+	gormLogger.ResetStats()
+	if stats, _ := gormLogger.QueryStats("", 0); len(stats) != 0 {
+		t.Errorf("stats after reset = %+v", stats)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	gormLogger := (&ZapGormLogger{LogLevel: ormogger.Silent}).WithStats(true)
+	gormLogger.Trace(context.Background(), time.Now(), func() (string, int64) { return "SELECT 1", 1 }, nil)
+	handler := gormLogger.StatsHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?order=count&limit=5", nil))
+	var stats []QueryStat
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || len(stats) != 1 || stats[0].Fingerprint != "SELECT ?" {
+		t.Errorf("code = %d, stats = %+v", rec.Code, stats)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?order=bad", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad order code = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("delete code = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
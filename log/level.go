@@ -0,0 +1,204 @@
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var ErrInvalidLevel = errors.New("无效的日志级别")
+
+// LevelPath LevelHandler 默认的挂载路径
+const LevelPath = "/debug/log/level"
+
+var (
+	moduleLevelsMux sync.Mutex
+	moduleLevels    = map[string]*moduleLevel{}
+)
+
+// moduleLevel 模块的日志级别，未单独设置时与默认级别一致
+type moduleLevel struct {
+	mux   sync.RWMutex
+	level *zapcore.Level
+}
+
+func (m *moduleLevel) Enabled(level zapcore.Level) bool {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	if m.level == nil {
+		return atomicLevel.Enabled(level)
+	}
+	return m.level.Enabled(level)
+}
+
+func (m *moduleLevel) set(level *zapcore.Level) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.level = level
+}
+
+func (m *moduleLevel) get() *zapcore.Level {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	return m.level
+}
+
+func getModuleLevel(module string) *moduleLevel {
+	moduleLevelsMux.Lock()
+	defer moduleLevelsMux.Unlock()
+	level, ok := moduleLevels[module]
+	if !ok {
+		level = &moduleLevel{}
+		moduleLevels[module] = level
+	}
+	return level
+}
+
+// Module
+//
+//	@Description: 返回名称为 module 的 logger，可通过 SetModuleLevel 单独设置级别；需在 Init 之后调用
+//	@param module
+//	@return *zap.SugaredLogger
+func Module(module string) *zap.SugaredLogger {
+	level := getModuleLevel(module)
+	outputsMux.Lock()
+	core := outputsCore(level)
+	outputsMux.Unlock()
+	return zap.New(core, zap.AddCaller(), zap.Development()).Named(module).Sugar()
+}
+
+// SetModuleLevel
+//
+//	@Description: 实时修改模块的日志级别，只影响未单独设置级别的输出
+//	@param module
+//	@param level 为空时恢复为默认级别
+//	@return error
+func SetModuleLevel(module, level string) error {
+	if level == "" {
+		getModuleLevel(module).set(nil)
+		return nil
+	}
+	l, err := checkLevel(level)
+	if err != nil {
+		return err
+	}
+	getModuleLevel(module).set(&l)
+	return nil
+}
+
+// checkLevel 与 SetLogLevel 支持的级别相同，未知的级别返回 ErrInvalidLevel
+func checkLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug", "info", "warn", "error", "panic", "fatal":
+		return parseLevel(level), nil
+	default:
+		return zapcore.InfoLevel, fmt.Errorf("%w: %s", ErrInvalidLevel, level)
+	}
+}
+
+// Levels 当前的日志级别
+type Levels struct {
+	Level   string            `json:"level"`             // 默认级别
+	Modules map[string]string `json:"modules,omitempty"` // 单独设置了级别的模块
+	Sinks   map[string]string `json:"sinks,omitempty"`   // 设置了 Level 的 Sink
+}
+
+// GetLevels
+//
+//	@Description: 获取当前的默认级别、模块级别和 Sink 级别
+//	@return Levels
+func GetLevels() Levels {
+	levels := Levels{
+		Level:   atomicLevel.Level().String(),
+		Modules: map[string]string{},
+		Sinks:   map[string]string{},
+	}
+	moduleLevelsMux.Lock()
+	for module, level := range moduleLevels {
+		if l := level.get(); l != nil {
+			levels.Modules[module] = l.String()
+		}
+	}
+	moduleLevelsMux.Unlock()
+
+	sinkLevelsMux.Lock()
+	for name, level := range sinkLevels {
+		levels.Sinks[name] = level.Level().String()
+	}
+	sinkLevelsMux.Unlock()
+	return levels
+}
+
+// levelRequest PUT 请求的内容，module 与 sink 都为空时修改默认级别
+type levelRequest struct {
+	Level  string `json:"level"`
+	Module string `json:"module"`
+	Sink   string `json:"sink"`
+}
+
+// LevelHandler
+//
+//	@Description: 查看和修改日志级别的 http.Handler
+//	GET 返回 Levels；PUT 的 body 为 {"level":"debug"}，可选 "module" 或 "sink" 修改模块或 Sink 的级别，也支持同名的 query 参数
+//	@return http.Handler
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			if err := setLevels(r); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GetLevels())
+	})
+}
+
+// setLevels 按请求修改级别
+func setLevels(r *http.Request) error {
+	req := levelRequest{
+		Level:  r.URL.Query().Get("level"),
+		Module: r.URL.Query().Get("module"),
+		Sink:   r.URL.Query().Get("sink"),
+	}
+	if req.Level == "" {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return fmt.Errorf("请求解析失败: %w", err)
+		}
+	}
+	switch {
+	case req.Module != "":
+		return SetModuleLevel(req.Module, req.Level)
+	case req.Sink != "":
+		if _, err := checkLevel(req.Level); err != nil {
+			return err
+		}
+		return SetSinkLevel(req.Sink, req.Level)
+	default:
+		if _, err := checkLevel(req.Level); err != nil {
+			return err
+		}
+		SetLogLevel(req.Level)
+		return nil
+	}
+}
+
+// RegisterHandlers
+//
+//	@Description: 将 LevelHandler 注册到 LevelPath，用法与 pprof.RegisterHandlers 相同
+//	@param h
+func RegisterHandlers(h func(pattern string, handler func(http.ResponseWriter, *http.Request))) {
+	h(LevelPath, LevelHandler().ServeHTTP)
+}
@@ -0,0 +1,110 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/natefinch/lumberjack"
+)
+
+func TestLevelHandler(t *testing.T) {
+	defer Init(nil)
+	defer SetLogLevel("debug")
+	Init(&Config{Sinks: []Sink{{Name: "error", Level: "error", LumberjackCfg: &lumberjack.Logger{Filename: filepath.Join(t.TempDir(), "error.log")}}}})
+	SetLogLevel("info")
+	handler := LevelHandler()
+
+	do := func(method, target, body string) (int, Levels) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var levels Levels
+		if rec.Code == http.StatusOK {
+			if err := json.Unmarshal(rec.Body.Bytes(), &levels); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rec.Code, levels
+	}
+
+	code, levels := do(http.MethodGet, LevelPath, "")
+	if code != http.StatusOK || levels.Level != "info" || levels.Sinks["error"] != "error" {
+		t.Errorf("GET = %d %+v", code, levels)
+	}
+	code, levels = do(http.MethodPut, LevelPath, `{"level":"debug"}`)
+	if code != http.StatusOK || levels.Level != "debug" {
+		t.Errorf("PUT = %d %+v", code, levels)
+	}
+	code, levels = do(http.MethodPut, LevelPath+"?module=db&level=warn", "")
+	if code != http.StatusOK || levels.Modules["db"] != "warn" {
+		t.Errorf("PUT module = %d %+v", code, levels)
+	}
+	code, levels = do(http.MethodPut, LevelPath, `{"sink":"error","level":"warn"}`)
+	if code != http.StatusOK || levels.Sinks["error"] != "warn" {
+		t.Errorf("PUT sink = %d %+v", code, levels)
+	}
+	if code, _ = do(http.MethodPut, LevelPath, `{"level":"verbose"}`); code != http.StatusBadRequest {
+		t.Errorf("PUT invalid = %d", code)
+	}
+	if code, _ = do(http.MethodPut, LevelPath, `{"sink":"unknown","level":"warn"}`); code != http.StatusBadRequest {
+		t.Errorf("PUT unknown sink = %d", code)
+	}
+	if code, _ = do(http.MethodDelete, LevelPath, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE = %d", code)
+	}
+	_ = SetModuleLevel("db", "")
+}
+
+func TestModuleLevel(t *testing.T) {
+	defer Init(nil)
+	defer SetLogLevel("debug")
+	file := filepath.Join(t.TempDir(), "app.log")
+	Init(&Config{LumberjackCfg: &lumberjack.Logger{Filename: file}})
+	SetLogLevel("info")
+	if err := SetModuleLevel("db", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = SetModuleLevel("db", "") }()
+
+	Module("db").Debug("db debug")
+	Module("http").Debug("http debug")
+	WithCtx(context.Background()).Debug("default debug")
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(data)
+	if !(strings.Contains(out, "\tdb\t") && strings.Contains(out, "db debug")) || strings.Contains(out, "http debug") || strings.Contains(out, "default debug") {
+		t.Errorf("output = %q", out)
+	}
+	if err := SetModuleLevel("db", "verbose"); err == nil {
+		t.Error("want ErrInvalidLevel")
+	}
+}
+
+func TestSetLogLevelConcurrent(t *testing.T) {
+	defer SetLogLevel("debug")
+	var wg sync.WaitGroup
+	for _, level := range []string{"debug", "info", "warn", "error"} {
+		wg.Go(func() {
+			for range 100 {
+				SetLogLevel(level)
+				_ = GetLevels()
+			}
+		})
+	}
+	wg.Go(func() {
+		for range 10 {
+			Init(nil)
+		}
+	})
+	wg.Wait()
+}
@@ -3,8 +3,12 @@ package log
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/natefinch/lumberjack"
@@ -12,26 +16,66 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+var ErrSinkNotFound = errors.New("日志输出不存在")
+
 type Config struct {
 	LumberjackCfg *lumberjack.Logger // 写到文件
 	Stdout        bool               // 打印到控制台
+	StdoutEncoder *EncoderConfig     // 控制台的编码，为空时为带颜色的 console 格式
+	FileEncoder   *EncoderConfig     // 文件的编码，为空时为不带颜色的 console 格式
+	Sinks         []Sink             // 额外的输出，每个输出可以有独立的级别
+	Sampling      *SamplingConfig    // 采样，为空时不采样
+	Dedup         time.Duration      // 级别、msg 和字段都相同的日志在该时间内只输出一次，窗口结束时输出一条带重复次数的汇总，0 表示不去重
+}
+
+// Sink 一个额外的日志输出，例如只记录 error 的文件
+type Sink struct {
+	Name          string             // 名称，用于 SetSinkLevel
+	Level         string             // 级别，为空时与 SetLogLevel 设置的级别一致
+	Stdout        bool               // 打印到控制台
+	LumberjackCfg *lumberjack.Logger // 写到文件
+	Ring          *RingBuffer        // 保存到内存，用于查询最近的日志
+	Encoder       *EncoderConfig     // 为空时控制台为带颜色的 console 格式，文件为不带颜色的 console 格式
+}
+
+// Encoding 日志编码格式
+type Encoding string
+
+const (
+	EncodingConsole Encoding = "console" // 便于阅读的文本格式
+	EncodingJSON    Encoding = "json"    // 每行一个 json，便于 Loki、ELK 采集
+)
+
+// EncoderConfig 单个输出的编码配置
+type EncoderConfig struct {
+	Encoding   Encoding // 为空时为 console
+	Color      bool     // 级别是否带颜色，仅 console 格式有效
+	TimeFormat string   // 时间格式，为空时为 2006-01-02 15:04:05
 }
 
 var (
 	logTmFmt    = "2006-01-02 15:04:05"
 	logger      *zap.SugaredLogger
 	atomicLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+	levelMux    sync.Mutex // 保护 logLevel 和 initLevel，SetLogLevel 可能被 LevelHandler 和信号并发调用
 	logLevel    = zap.InfoLevel
 	initLevel   = "debug"
 
 	defaultConfig = &Config{
 		Stdout: true,
 	}
-	defaultLogger = zap.New(newCore(defaultConfig), zap.AddCaller(), zap.Development()).Sugar()
+	defaultStdoutEncoder = &EncoderConfig{Encoding: EncodingConsole, Color: true}
+	defaultFileEncoder   = &EncoderConfig{Encoding: EncodingConsole}
+	defaultLogger        = zap.New(newCore(defaultConfig), zap.AddCaller(), zap.Development()).Sugar()
 )
 
 var lumberjackLogger *lumberjack.Logger
 
+var (
+	sinkLevelsMux sync.Mutex
+	sinkLevels    = map[string]zap.AtomicLevel{}
+)
+
 func LoggerIsNil() bool {
 	return logger == nil
 }
@@ -41,28 +85,55 @@ func LoggerIsNil() bool {
 //	@Description:默认级别是debug，实时修改日志级别
 //	@param level
 func SetLogLevel(level string) {
+	levelMux.Lock()
+	defer levelMux.Unlock()
 	initLevel = level
-	setLogLevel()
+	logLevel = parseLevel(initLevel)
+	atomicLevel.SetLevel(logLevel)
 }
 
 func setLogLevel() {
-	switch strings.ToLower(initLevel) {
+	levelMux.Lock()
+	defer levelMux.Unlock()
+	logLevel = parseLevel(initLevel)
+	atomicLevel.SetLevel(logLevel)
+}
+
+// SetSinkLevel
+//
+//	@Description: 实时修改 Config.Sinks 中设置了 Level 的输出的级别
+//	@param name Sink.Name
+//	@param level
+//	@return error 不存在或未设置 Level 时返回 ErrSinkNotFound
+func SetSinkLevel(name, level string) error {
+	sinkLevelsMux.Lock()
+	defer sinkLevelsMux.Unlock()
+	sinkLevel, ok := sinkLevels[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSinkNotFound, name)
+	}
+	sinkLevel.SetLevel(parseLevel(level))
+	return nil
+}
+
+// parseLevel 未知的级别为 info
+func parseLevel(level string) zapcore.Level {
+	switch strings.ToLower(level) {
 	case "debug":
-		logLevel = zap.DebugLevel
+		return zap.DebugLevel
 	case "info":
-		logLevel = zap.InfoLevel
+		return zap.InfoLevel
 	case "warn":
-		logLevel = zap.WarnLevel
+		return zap.WarnLevel
 	case "error":
-		logLevel = zap.ErrorLevel
+		return zap.ErrorLevel
 	case "panic":
-		logLevel = zap.PanicLevel
+		return zap.PanicLevel
 	case "fatal":
-		logLevel = zap.FatalLevel
+		return zap.FatalLevel
 	default:
-		logLevel = zap.InfoLevel
+		return zap.InfoLevel
 	}
-	atomicLevel.SetLevel(logLevel)
 }
 
 func Init(cfg *Config) {
@@ -76,42 +147,160 @@ func Init(cfg *Config) {
 }
 
 func InitBuffer(logBuffer *bytes.Buffer) {
-	core := zapcore.NewCore(
-		zapcore.NewConsoleEncoder(newEncoderConfig()),
-		zapcore.NewMultiWriteSyncer(zapcore.AddSync(logBuffer), zapcore.AddSync(os.Stdout)), // 打印到控制台和文件
-		atomicLevel, // 日志级别
-	)
+	outputsMux.Lock()
+	outputs = []output{{
+		encoder: zapcore.NewConsoleEncoder(newEncoderConfig()),
+		writer:  zapcore.NewMultiWriteSyncer(zapcore.AddSync(logBuffer), zapcore.AddSync(os.Stdout)), // 打印到控制台和文件
+	}}
+	sampling, dedup = nil, 0
+	core := outputsCore(atomicLevel)
+	outputsMux.Unlock()
 	l := zap.New(core, zap.AddCaller(), zap.Development())
 	logger = l.Sugar()
 	setLogLevel()
 }
 
+// output 一个日志输出，level 为空时使用默认级别；ring 不为空时写入 ring，忽略 encoder 和 writer
+type output struct {
+	encoder zapcore.Encoder
+	writer  zapcore.WriteSyncer
+	ring    *RingBuffer
+	level   zapcore.LevelEnabler
+}
+
+var (
+	outputsMux sync.Mutex
+	outputs    []output // 最近一次 Init 创建的输出，Module 复用这些输出
+	sampling   *SamplingConfig
+	dedup      time.Duration
+)
+
 func newCore(cfg *Config) zapcore.Core {
 	// 设置级别
+	levelMux.Lock()
 	atomicLevel.SetLevel(logLevel)
+	levelMux.Unlock()
 
-	var wsList []zapcore.WriteSyncer
+	var list []output
 	if cfg.Stdout {
-		wsList = append(wsList, zapcore.AddSync(os.Stdout))
+		list = append(list, output{
+			encoder: newEncoder(cfg.StdoutEncoder, defaultStdoutEncoder),
+			writer:  zapcore.AddSync(os.Stdout),
+		})
 	}
 	if cfg.LumberjackCfg != nil {
-		lumberjackLogger = &lumberjack.Logger{
-			Filename:   cfg.LumberjackCfg.Filename,   // 日志文件存放目录，如果文件夹不存在会自动创建
-			MaxSize:    cfg.LumberjackCfg.MaxSize,    // 文件大小限制,单位100MB
-			MaxBackups: cfg.LumberjackCfg.MaxBackups, // 最大保留日志文件数量
-			MaxAge:     cfg.LumberjackCfg.MaxAge,     // 日志文件保留天数
-			Compress:   cfg.LumberjackCfg.Compress,   // 是否压缩处理
-			LocalTime:  cfg.LumberjackCfg.LocalTime,
+		lumberjackLogger = newLumberjack(cfg.LumberjackCfg)
+		list = append(list, output{
+			encoder: newEncoder(cfg.FileEncoder, defaultFileEncoder),
+			writer:  zapcore.AddSync(lumberjackLogger),
+		})
+	}
+	list = append(list, newSinkOutputs(cfg.Sinks)...)
+
+	outputsMux.Lock()
+	defer outputsMux.Unlock()
+	outputs = list
+	sampling = cfg.Sampling
+	dedup = cfg.Dedup
+	return outputsCore(atomicLevel)
+}
+
+// outputsCore
+//
+//	@Description: 由 outputs 创建 core，每个输出使用独立的编码和级别，调用方需持有 outputsMux
+//	@param defaultLevel 未设置级别的输出使用的级别
+//	@return zapcore.Core
+func outputsCore(defaultLevel zapcore.LevelEnabler) zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(outputs))
+	for _, o := range outputs {
+		level := o.level
+		if level == nil {
+			level = defaultLevel
+		}
+		if o.ring != nil {
+			cores = append(cores, newRingCore(o.ring, level))
+			continue
+		}
+		cores = append(cores, zapcore.NewCore(o.encoder, o.writer, level))
+	}
+	return wrapCore(zapcore.NewTee(cores...), sampling, dedup)
+}
+
+// newSinkOutputs
+//
+//	@Description: 为每个 Sink 创建输出，设置了 Level 的 Sink 使用独立的级别
+//	@param sinks
+//	@return []output
+func newSinkOutputs(sinks []Sink) []output {
+	sinkLevelsMux.Lock()
+	defer sinkLevelsMux.Unlock()
+	clear(sinkLevels)
+
+	list := make([]output, 0, len(sinks))
+	for _, sink := range sinks {
+		var level zapcore.LevelEnabler
+		if sink.Level != "" {
+			sinkLevel := zap.NewAtomicLevelAt(parseLevel(sink.Level))
+			if sink.Name != "" {
+				sinkLevels[sink.Name] = sinkLevel
+			}
+			level = sinkLevel
+		}
+		if sink.Stdout {
+			list = append(list, output{
+				encoder: newEncoder(sink.Encoder, defaultStdoutEncoder),
+				writer:  zapcore.AddSync(os.Stdout),
+				level:   level,
+			})
+		}
+		if sink.LumberjackCfg != nil {
+			list = append(list, output{
+				encoder: newEncoder(sink.Encoder, defaultFileEncoder),
+				writer:  zapcore.AddSync(newLumberjack(sink.LumberjackCfg)),
+				level:   level,
+			})
 		}
-		infoFileWriteSyncer := zapcore.AddSync(lumberjackLogger)
-		wsList = append(wsList, zapcore.AddSync(infoFileWriteSyncer))
+		if sink.Ring != nil {
+			list = append(list, output{ring: sink.Ring, level: level})
+		}
+	}
+	return list
+}
+
+// newLumberjack 复制 lumberjack 的配置
+func newLumberjack(cfg *lumberjack.Logger) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   cfg.Filename,   // 日志文件存放目录，如果文件夹不存在会自动创建
+		MaxSize:    cfg.MaxSize,    // 文件大小限制,单位100MB
+		MaxBackups: cfg.MaxBackups, // 最大保留日志文件数量
+		MaxAge:     cfg.MaxAge,     // 日志文件保留天数
+		Compress:   cfg.Compress,   // 是否压缩处理
+		LocalTime:  cfg.LocalTime,
+	}
+}
+
+// newEncoder
+//
+//	@Description: 根据配置创建编码器
+//	@param cfg 为空时使用 def
+//	@param def
+//	@return zapcore.Encoder
+func newEncoder(cfg, def *EncoderConfig) zapcore.Encoder {
+	if cfg == nil {
+		cfg = def
+	}
+	encoderConfig := newEncoderConfig()
+	if cfg.TimeFormat != "" {
+		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(cfg.TimeFormat)
+	}
+	if cfg.Encoding == EncodingJSON {
+		encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+		return zapcore.NewJSONEncoder(encoderConfig)
 	}
-	return zapcore.NewCore(
-		zapcore.NewConsoleEncoder(newEncoderConfig()),
-		//		zapcore.NewJSONEncoder(encoderConfig), // 编码器配置
-		zapcore.NewMultiWriteSyncer(wsList...), // 打印到控制台和文件
-		atomicLevel,                            // 日志级别
-	)
+	if !cfg.Color {
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
 }
 
 func newEncoderConfig() zapcore.EncoderConfig {
@@ -145,7 +334,7 @@ func GetLogger() *zap.SugaredLogger {
 	return logger
 }
 
-// WithCtx 返回一个带有 request_id 的 SugaredLogger
+// WithCtx 返回一个带有 request_id 及 ctx 中其他字段（trace_id、span_id 等）的 SugaredLogger
 // 注意: 返回的 logger 会继承全局 logger 的 callerSkip 设置
 // 在热路径上建议缓存结果: l := log.WithCtx(ctx); l.Info(...)
 func WithCtx(ctx context.Context) *zap.SugaredLogger {
@@ -155,22 +344,17 @@ func WithCtx(ctx context.Context) *zap.SugaredLogger {
 		base = defaultLogger
 	}
 
-	// 2. 没有 ctx 直接返回
-	if ctx == nil {
-		return base
-	}
-
-	// 3. 提取 request_id
-	if requestId, ok := ctx.Value("request_id").(string); ok && requestId != "" {
-		return base.With("request_id", requestId)
+	// 2. 提取 request_id 及其他字段，没有 ctx 直接返回
+	if fields := Fields(ctx); len(fields) > 0 {
+		return base.With(fields...)
 	}
 
 	return base
 }
 
+// SetRequestId 在 ctx 中设置一个新生成的 request_id
 func SetRequestId(ctx context.Context) context.Context {
-	ctx = context.WithValue(ctx, "request_id", uuid.New().String())
-	return ctx
+	return WithRequestID(ctx, uuid.New().String())
 }
 
 // WithCtxSkip 提供自定义 caller 层级跳过，解决封装导致的行号错误
@@ -2,7 +2,15 @@ package log
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/natefinch/lumberjack"
 )
 
 func TestSetLogLevel(t *testing.T) {
@@ -25,3 +33,94 @@ func TestSetLogLevel(t *testing.T) {
 	WithCtx(context.Background()).Debug("Debug")
 	WithCtx(context.Background()).Info("Info")
 }
+
+func TestFileEncoder(t *testing.T) {
+	defer Init(nil)
+	for _, c := range []struct {
+		name    string
+		encoder *EncoderConfig
+		check   func(line string) bool
+	}{
+		{"default", nil, func(line string) bool {
+			return strings.Contains(line, "\tINFO\t") && !strings.Contains(line, "\x1b[")
+		}},
+		{"json", &EncoderConfig{Encoding: EncodingJSON, TimeFormat: time.RFC3339}, func(line string) bool {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				return false
+			}
+			_, err := time.Parse(time.RFC3339, entry["ts"].(string))
+			return err == nil && entry["level"] == "info" && entry["msg"] == "hello" && entry["request_id"] != nil
+		}},
+		{"color", &EncoderConfig{Color: true}, func(line string) bool {
+			return strings.Contains(line, "\x1b[")
+		}},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "app.log")
+			Init(&Config{
+				LumberjackCfg: &lumberjack.Logger{Filename: file},
+				FileEncoder:   c.encoder,
+			})
+			WithCtx(SetRequestId(context.Background())).Info("hello")
+			_ = GetLogger().Sync()
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if line := strings.TrimSpace(string(data)); !c.check(line) {
+				t.Errorf("line = %q", line)
+			}
+		})
+	}
+}
+
+func TestSinks(t *testing.T) {
+	defer Init(nil)
+	dir := t.TempDir()
+	debugFile := filepath.Join(dir, "debug.log")
+	errorFile := filepath.Join(dir, "error.log")
+	followFile := filepath.Join(dir, "follow.log")
+	Init(&Config{
+		Sinks: []Sink{
+			{Name: "debug", Level: "debug", LumberjackCfg: &lumberjack.Logger{Filename: debugFile}},
+			{Name: "error", Level: "error", LumberjackCfg: &lumberjack.Logger{Filename: errorFile}},
+			{Name: "follow", LumberjackCfg: &lumberjack.Logger{Filename: followFile}},
+		},
+	})
+	SetLogLevel("info")
+	defer SetLogLevel("debug")
+	l := WithCtx(context.Background())
+	l.Debug("debug msg")
+	l.Info("info msg")
+	l.Error("error msg")
+
+	if err := SetSinkLevel("error", "warn"); err != nil {
+		t.Fatal(err)
+	}
+	l.Warn("warn msg")
+	if err := SetSinkLevel("follow", "debug"); !errors.Is(err, ErrSinkNotFound) {
+		t.Errorf("err = %v", err)
+	}
+
+	for file, want := range map[string][]string{
+		debugFile:  {"debug msg", "info msg", "error msg", "warn msg"},
+		errorFile:  {"error msg", "warn msg"},
+		followFile: {"info msg", "error msg", "warn msg"},
+	} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != len(want) {
+			t.Errorf("%s = %q", filepath.Base(file), lines)
+			continue
+		}
+		for i := range want {
+			if !strings.Contains(lines[i], want[i]) {
+				t.Errorf("%s line %d = %q, want %q", filepath.Base(file), i, lines[i], want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// Entry 一条结构化日志
+type Entry struct {
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level"`
+	Logger  string         `json:"logger,omitempty"`
+	Caller  string         `json:"caller,omitempty"`
+	Message string         `json:"msg"`
+	Fields  map[string]any `json:"fields,omitempty"`
+	seq     uint64         // 写入 RingBuffer 的序号，用于 stream 区分历史和新日志
+}
+
+// RingBuffer 保存最近 N 条日志的输出，超出后覆盖最早的日志
+type RingBuffer struct {
+	mux     sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	seq     uint64
+	subs    map[chan Entry]struct{}
+}
+
+// ringSubBuffer 订阅 channel 的缓冲大小
+const ringSubBuffer = 256
+
+// NewRingBuffer
+//
+//	@Description: 创建保存最近 size 条日志的 RingBuffer，可作为 Sink.Ring 使用
+//	@param size 小于 1 时为 1000
+//	@return *RingBuffer
+func NewRingBuffer(size int) *RingBuffer {
+	if size < 1 {
+		size = 1000
+	}
+	return &RingBuffer{entries: make([]Entry, size)}
+}
+
+// InitRing
+//
+//	@Description: 与 InitBuffer 相同，打印到控制台的同时保存最近 size 条日志，内存占用有上限
+//	@param size
+//	@return *RingBuffer
+func InitRing(size int) *RingBuffer {
+	ring := NewRingBuffer(size)
+	Init(&Config{Stdout: true, Sinks: []Sink{{Ring: ring}}})
+	return ring
+}
+
+// add 添加一条日志并发送给订阅者
+func (r *RingBuffer) add(entry Entry) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.seq++
+	entry.seq = r.seq
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	for ch := range r.subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+}
+
+// Entries
+//
+//	@Description: 按时间顺序返回保存的所有日志
+//	@receiver r
+//	@return []Entry
+func (r *RingBuffer) Entries() []Entry {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.full {
+		return append([]Entry{}, r.entries[:r.next]...)
+	}
+	return append(append([]Entry{}, r.entries[r.next:]...), r.entries[:r.next]...)
+}
+
+// Query 日志查询条件，零值的条件不生效
+type Query struct {
+	Level     string    // 最低级别
+	Since     time.Time // 开始时间（含）
+	Until     time.Time // 结束时间（不含）
+	RequestID string    // request_id 字段
+	Contains  string    // msg 或字段值包含的字符串
+	Limit     int       // 最多返回最近的多少条
+}
+
+// match 日志是否满足查询条件
+func (q *Query) match(entry *Entry) bool {
+	if q.Level != "" {
+		level, err := zapcore.ParseLevel(entry.Level)
+		if err == nil && level < parseLevel(q.Level) {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !entry.Time.Before(q.Until) {
+		return false
+	}
+	if q.RequestID != "" && entry.Fields[FieldRequestID] != q.RequestID {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(entry.Message, q.Contains) {
+		for _, value := range entry.Fields {
+			if strings.Contains(fmt.Sprint(value), q.Contains) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Query
+//
+//	@Description: 按条件查询保存的日志，按时间顺序返回
+//	@receiver r
+//	@param q
+//	@return []Entry
+func (r *RingBuffer) Query(q Query) []Entry {
+	return q.filter(r.Entries())
+}
+
+// filter 返回 all 中满足条件的日志
+func (q *Query) filter(all []Entry) []Entry {
+	var entries []Entry
+	for _, entry := range all {
+		if q.match(&entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+	return entries
+}
+
+// Subscribe
+//
+//	@Description: 订阅新的日志，订阅者消费过慢时日志会被丢弃，不会阻塞写日志
+//	@receiver r
+//	@return <-chan Entry
+//	@return func() 取消订阅并关闭 channel，可重复调用
+func (r *RingBuffer) Subscribe() (<-chan Entry, func()) {
+	ch := make(chan Entry, ringSubBuffer)
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if r.subs == nil {
+		r.subs = make(map[chan Entry]struct{})
+	}
+	r.subs[ch] = struct{}{}
+	return ch, func() {
+		r.mux.Lock()
+		defer r.mux.Unlock()
+		if _, ok := r.subs[ch]; ok {
+			delete(r.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Handler
+//
+//	@Description: 查询日志的 http.Handler，query 参数为 level、since、until（RFC3339）、request_id、q、limit，格式错误时返回 400；
+//	follow=true 或 Accept 为 text/event-stream 时，以 SSE 先输出满足条件的日志，再持续输出新的日志
+//	@receiver r
+//	@return http.Handler
+func (r *RingBuffer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		q, err := parseQuery(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.URL.Query().Get("follow") == "true" || strings.Contains(req.Header.Get("Accept"), "text/event-stream") {
+			r.stream(w, req, q)
+			return
+		}
+		entries := r.Query(q)
+		if entries == nil {
+			entries = []Entry{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(entries)
+	})
+}
+
+// stream 以 SSE 输出日志，直到客户端断开
+func (r *RingBuffer) stream(w http.ResponseWriter, req *http.Request, q Query) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "不支持 SSE", http.StatusInternalServerError)
+		return
+	}
+	// 先订阅，避免输出历史日志期间丢失新日志
+	entries, cancel := r.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	// 按序号跳过历史中已有的日志，时间相同的日志也不会重复输出
+	history := r.Entries()
+	var last uint64
+	if len(history) > 0 {
+		last = history[len(history)-1].seq
+	}
+	for _, entry := range q.filter(history) {
+		writeEvent(w, entry)
+	}
+	flusher.Flush()
+
+	q.Limit = 0
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+		case entry := <-entries:
+			if entry.seq <= last || !q.match(&entry) {
+				continue
+			}
+			writeEvent(w, entry)
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent 输出一条 SSE 事件
+func writeEvent(w http.ResponseWriter, entry Entry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// parseQuery 从 query 参数解析查询条件
+func parseQuery(req *http.Request) (Query, error) {
+	values := req.URL.Query()
+	q := Query{
+		Level:     values.Get("level"),
+		RequestID: values.Get(FieldRequestID),
+		Contains:  values.Get("q"),
+	}
+	var err error
+	if q.Level != "" {
+		if _, err = checkLevel(q.Level); err != nil {
+			return q, err
+		}
+	}
+	if since := values.Get("since"); since != "" {
+		if q.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return q, fmt.Errorf("since 格式错误: %w", err)
+		}
+	}
+	if until := values.Get("until"); until != "" {
+		if q.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return q, fmt.Errorf("until 格式错误: %w", err)
+		}
+	}
+	if limit := values.Get("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil {
+			return q, fmt.Errorf("limit 格式错误: %w", err)
+		}
+	}
+	return q, nil
+}
+
+// ringCore 将日志写入 RingBuffer 的 zapcore.Core
+type ringCore struct {
+	zapcore.LevelEnabler
+	ring   *RingBuffer
+	fields []zapcore.Field
+}
+
+func newRingCore(ring *RingBuffer, level zapcore.LevelEnabler) zapcore.Core {
+	return &ringCore{LevelEnabler: level, ring: ring}
+}
+
+func (c *ringCore) With(fields []zapcore.Field) zapcore.Core {
+	return &ringCore{
+		LevelEnabler: c.LevelEnabler,
+		ring:         c.ring,
+		fields:       append(append([]zapcore.Field{}, c.fields...), fields...),
+	}
+}
+
+func (c *ringCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *ringCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	e := Entry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Logger:  entry.LoggerName,
+		Message: entry.Message,
+	}
+	if entry.Caller.Defined {
+		e.Caller = entry.Caller.TrimmedPath()
+	}
+	if len(enc.Fields) > 0 {
+		e.Fields = enc.Fields
+	}
+	c.ring.add(e)
+	return nil
+}
+
+func (c *ringCore) Sync() error {
+	return nil
+}
+
+var _ zapcore.Core = (*ringCore)(nil)
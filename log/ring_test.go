@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer(t *testing.T) {
+	defer Init(nil)
+	ring := NewRingBuffer(3)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}})
+	ctx := WithRequestID(context.Background(), "req-1")
+	WithCtx(context.Background()).Debug("dropped")
+	WithCtx(context.Background()).Info("first")
+	WithCtx(ctx).Warn("second")
+	WithCtx(ctx).With("host", "node-1").Error("third")
+
+	entries := ring.Entries()
+	if len(entries) != 3 || entries[0].Message != "first" || entries[2].Message != "third" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if entries[2].Fields[FieldRequestID] != "req-1" || entries[2].Fields["host"] != "node-1" || entries[2].Caller == "" {
+		t.Errorf("entry = %+v", entries[2])
+	}
+
+	for _, c := range []struct {
+		q    Query
+		want string
+	}{
+		{Query{Level: "warn"}, "second,third"},
+		{Query{RequestID: "req-1", Limit: 1}, "third"},
+		{Query{Contains: "node-1"}, "third"},
+		{Query{Contains: "fir"}, "first"},
+		{Query{Since: entries[1].Time, Until: entries[2].Time}, "second"},
+	} {
+		var got []string
+		for _, entry := range ring.Query(c.q) {
+			got = append(got, entry.Message)
+		}
+		if strings.Join(got, ",") != c.want {
+			t.Errorf("query %+v = %q, want %q", c.q, got, c.want)
+		}
+	}
+}
+
+func TestRingBufferHandler(t *testing.T) {
+	defer Init(nil)
+	ring := InitRing(10)
+	WithCtx(context.Background()).Info("hello")
+	WithCtx(context.Background()).Error("boom")
+
+	rec := httptest.NewRecorder()
+	ring.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=error", nil))
+	var entries []Entry
+	if err := json.Unmarshal(rec.Body.Bytes(), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Message != "boom" {
+		t.Errorf("entries = %+v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	ring.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?limit=x", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("code = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	ring.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?level=verbose", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("code = %d", rec.Code)
+	}
+
+	server := httptest.NewServer(ring.Handler())
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?follow=true&q=boom", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	WithCtx(context.Background()).Error("boom again")
+
+	scanner := bufio.NewScanner(resp.Body)
+	var got []string
+	for scanner.Scan() && len(got) < 2 {
+		line, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, entry.Message)
+	}
+	if strings.Join(got, ",") != "boom,boom again" {
+		t.Errorf("stream = %q", got)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package log
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+)
+
+// HandleLevelSignals
+//
+//	@Description: 收到 SIGUSR1 时将默认级别切换为 debug，收到 SIGUSR2 时恢复为切换前的级别，ctx 结束后停止监听
+//	@param ctx
+func HandleLevelSignals(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		previous := ""
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				switch {
+				case sig == syscall.SIGUSR1 && atomicLevel.Level() != zap.DebugLevel:
+					previous = atomicLevel.Level().String()
+					SetLogLevel("debug")
+				case sig == syscall.SIGUSR2 && previous != "":
+					SetLogLevel(previous)
+					previous = ""
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package log
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleLevelSignals(t *testing.T) {
+	defer SetLogLevel("debug")
+	SetLogLevel("warn")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	HandleLevelSignals(ctx)
+
+	waitLevel := func(want string) {
+		t.Helper()
+		for range 100 {
+			if atomicLevel.Level().String() == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("level = %s, want %s", atomicLevel.Level(), want)
+	}
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel("debug")
+	_ = syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel("warn")
+}
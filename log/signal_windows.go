@@ -0,0 +1,10 @@
+//go:build windows
+
+package log
+
+import (
+	"context"
+)
+
+// HandleLevelSignals Windows 不支持 SIGUSR1、SIGUSR2
+func HandleLevelSignals(_ context.Context) {}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// SlogHandler 将 log/slog 的日志写入与 GetLogger 相同的 core，使用相同的级别、格式、输出和 context 字段
+type SlogHandler struct {
+	fields []zap.Field // WithAttrs、WithGroup 累积的字段
+}
+
+var _ slog.Handler = (*SlogHandler)(nil)
+
+// NewSlogHandler
+//
+//	@Description: 创建 slog.Handler，每次输出时使用当前的全局 logger，Init 之后无需重新创建
+//	@return *SlogHandler
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{}
+}
+
+// SetSlogDefault
+//
+//	@Description: 将 slog 的默认 logger 设置为使用 SlogHandler，标准库 log 的输出也会经过 SlogHandler
+func SetSlogDefault() {
+	slog.SetDefault(slog.New(NewSlogHandler()))
+}
+
+// core 当前全局 logger 的 core
+func (h *SlogHandler) core() zapcore.Core {
+	base := logger
+	if base == nil {
+		base = defaultLogger
+	}
+	return base.Desugar().Core()
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core().Enabled(slogLevel(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, record slog.Record) error {
+	entry := zapcore.Entry{
+		Level:   slogLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		entry.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		entry.Caller.Function = frame.Function
+	}
+	ce := h.core().Check(entry, nil)
+	if ce == nil {
+		return nil
+	}
+
+	// context 字段在最前面，不受 WithGroup 影响
+	kv := Fields(ctx)
+	fields := make([]zap.Field, 0, len(kv)/2+len(h.fields)+record.NumAttrs())
+	for i := 0; i+1 < len(kv); i += 2 {
+		key, _ := kv[i].(string)
+		fields = append(fields, zap.Any(key, kv[i+1]))
+	}
+	fields = append(fields, h.fields...)
+	record.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, attr)
+		return true
+	})
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := append([]zap.Field{}, h.fields...)
+	for _, attr := range attrs {
+		fields = appendAttr(fields, attr)
+	}
+	return &SlogHandler{fields: fields}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{fields: append(append([]zap.Field{}, h.fields...), zap.Namespace(name))}
+}
+
+// slogLevel slog 级别转换为 zap 级别
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// appendAttr 将 slog.Attr 转换为 zap.Field，空的 Attr 会被忽略
+func appendAttr(fields []zap.Field, attr slog.Attr) []zap.Field {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return append(fields, zap.String(attr.Key, attr.Value.String()))
+	case slog.KindInt64:
+		return append(fields, zap.Int64(attr.Key, attr.Value.Int64()))
+	case slog.KindUint64:
+		return append(fields, zap.Uint64(attr.Key, attr.Value.Uint64()))
+	case slog.KindFloat64:
+		return append(fields, zap.Float64(attr.Key, attr.Value.Float64()))
+	case slog.KindBool:
+		return append(fields, zap.Bool(attr.Key, attr.Value.Bool()))
+	case slog.KindDuration:
+		return append(fields, zap.Duration(attr.Key, attr.Value.Duration()))
+	case slog.KindTime:
+		return append(fields, zap.Time(attr.Key, attr.Value.Time()))
+	case slog.KindGroup:
+		var group []zap.Field
+		for _, a := range attr.Value.Group() {
+			group = appendAttr(group, a)
+		}
+		if len(group) == 0 {
+			return fields
+		}
+		// 没有 key 的 group 直接展开
+		if attr.Key == "" {
+			return append(fields, group...)
+		}
+		return append(fields, zap.Dict(attr.Key, group...))
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return append(fields, zap.NamedError(attr.Key, err))
+		}
+		return append(fields, zap.Any(attr.Key, attr.Value.Any()))
+	}
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	defer Init(nil)
+	defer SetLogLevel("debug")
+	ring := NewRingBuffer(10)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}})
+	SetLogLevel("info")
+
+	l := slog.New(NewSlogHandler()).With("component", "db")
+	ctx := WithRequestID(context.Background(), "req-1")
+	l.DebugContext(ctx, "dropped")
+	l.WithGroup("conn").InfoContext(ctx, "connected",
+		"host", "127.0.0.1", "port", 3306, "elapsed", time.Second,
+		slog.Group("tls", "enabled", true), "err", errors.New("boom"))
+
+	entries := ring.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+	entry := entries[0]
+	if entry.Level != "info" || entry.Message != "connected" || !strings.HasPrefix(entry.Caller, "log/slog_test.go") {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Fields[FieldRequestID] != "req-1" || entry.Fields["component"] != "db" {
+		t.Errorf("fields = %v", entry.Fields)
+	}
+	conn, _ := entry.Fields["conn"].(map[string]any)
+	tls, _ := conn["tls"].(map[string]any)
+	if conn["host"] != "127.0.0.1" || conn["port"] != int64(3306) || conn["err"] != "boom" || tls["enabled"] != true {
+		t.Errorf("conn = %v", conn)
+	}
+}
+
+func TestSetSlogDefault(t *testing.T) {
+	defer Init(nil)
+	defer slog.SetDefault(slog.Default())
+	ring := NewRingBuffer(10)
+	Init(&Config{Sinks: []Sink{{Ring: ring}}})
+	SetSlogDefault()
+	slog.Warn("from slog")
+	if entries := ring.Entries(); len(entries) != 1 || entries[0].Level != "warn" || entries[0].Message != "from slog" {
+		t.Errorf("entries = %+v", entries)
+	}
+}
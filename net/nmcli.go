@@ -4,26 +4,33 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	"github.com/youcd/toolkit/bash"
 )
 
-type NMCli struct{}
+type NMCli struct {
+	Executor bash.Executor // 命令执行器，为 nil 时在本机执行
+}
 
 func NewNMcli() *NMCli {
-	return &NMCli{}
+	return &NMCli{Executor: bash.NewLocal()}
 }
 
 func (n *NMCli) SetNetWork(ctx context.Context, addresses, dns []string, gateway, cni string) error {
 	//  nmcli connection modify br0 ipv4.gateway 192.168.104.88
 	addressesStr := strings.Join(addresses, ",")
 	dnsStr := strings.Join(dns, ",")
-	cmdStr := fmt.Sprintf("nmcli connection modify %s ipv4.addresses %s ipv4.gateway %s ipv4.dns %s ifname %s", cni, addressesStr, gateway, dnsStr, cni)
-	err := bash(ctx, cmdStr)
+	err := run(ctx, n.Executor, "nmcli", "connection", "modify", cni,
+		"ipv4.addresses", addressesStr,
+		"ipv4.gateway", gateway,
+		"ipv4.dns", dnsStr,
+		"ifname", cni)
 	if err != nil {
-		return fmt.Errorf("bash: %w", err)
+		return fmt.Errorf("nmcli connection modify: %w", err)
 	}
-	err = bash(ctx, "nmcli connection up "+cni)
+	err = run(ctx, n.Executor, "nmcli", "connection", "up", cni)
 	if err != nil {
-		return fmt.Errorf("bash: %w", err)
+		return fmt.Errorf("nmcli connection up: %w", err)
 	}
 	return nil
 }
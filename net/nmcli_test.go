@@ -2,7 +2,10 @@ package net
 
 import (
 	"context"
+	"errors"
 	"testing"
+
+	"github.com/youcd/toolkit/bash"
 )
 
 func TestNMCli_SetNetWork(t *testing.T) {
@@ -16,3 +19,37 @@ func TestNMCli_SetNetWork(t *testing.T) {
 		t.Errorf("SetNetWork() error = %v", err)
 	}
 }
+
+func TestNMCli_SetNetWorkWithFake(t *testing.T) {
+	fake := bash.NewFake(bash.Fixture{Pattern: "^nmcli connection "})
+	n := &NMCli{Executor: fake}
+	err := n.SetNetWork(context.TODO(), []string{"192.168.111.166/23", "192.168.110.90/23"}, []string{"8.8.8.8", "223.5.5.5"}, "192.168.110.1", "ens 192")
+	if err != nil {
+		t.Fatalf("SetNetWork() error = %v", err)
+	}
+	want := []string{
+		"nmcli connection modify 'ens 192' ipv4.addresses 192.168.111.166/23,192.168.110.90/23 ipv4.gateway 192.168.110.1 ipv4.dns 8.8.8.8,223.5.5.5 ifname 'ens 192'",
+		"nmcli connection up 'ens 192'",
+	}
+	calls := fake.Calls()
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v", calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("calls[%d] = %s, want %s", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestNMCli_SetNetWorkError(t *testing.T) {
+	fake := bash.NewFake(bash.Fixture{Pattern: "^nmcli connection modify ", Stderr: "Error: unknown connection 'ens192'.\n", ExitCode: 10})
+	n := &NMCli{Executor: fake}
+	err := n.SetNetWork(context.TODO(), nil, nil, "192.168.110.1", "ens192")
+	if !errors.Is(err, ErrNetplanApply) {
+		t.Errorf("SetNetWork() error = %v", err)
+	}
+	if len(fake.Calls()) != 1 {
+		t.Errorf("calls = %v", fake.Calls())
+	}
+}
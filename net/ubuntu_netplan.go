@@ -4,9 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"time"
 
+	"github.com/youcd/toolkit/bash"
 	"github.com/youcd/toolkit/file"
 	"gopkg.in/yaml.v3"
 )
@@ -54,11 +54,13 @@ type Netplan struct {
 	NetworkObj     *NetworkObj `yaml:"network"`
 	ConfigFile     string
 	ConfigFileData []byte
+	Executor       bash.Executor `yaml:"-"` // 命令执行器，为 nil 时在本机执行
 }
 
 func NewNetplan(configFile string) (*Netplan, error) {
 	netplan := new(Netplan)
 	netplan.ConfigFile = configFile
+	netplan.Executor = bash.NewLocal()
 	err := netplan.ParserConfig()
 	if err != nil {
 		return nil, err
@@ -170,22 +172,28 @@ func (n *Netplan) SetNetWork(ctx context.Context, addresses, dns []string, gatew
 //	@receiver n
 //	@return error
 func (n *Netplan) apply(ctx context.Context) error {
-	cmdStr := "netplan apply"
-	return bash(ctx, cmdStr)
+	return run(ctx, n.Executor, "netplan", "apply")
 }
 
-func bash(ctx context.Context, cmdStr string) error {
-	command := exec.CommandContext(ctx, "bash", "-c", cmdStr)
-	command.Env = append(command.Environ(), "LANG=en_US.utf8", "LANGUAGE=en_US.utf8")
-	output, err := command.CombinedOutput()
-	if err != nil {
-		//nolint:errorlint
-		if ins, ok := err.(*exec.ExitError); ok {
-			out := string(output)
-			exitCode := ins.ExitCode()
-			return fmt.Errorf("out: %s, exit.code: %d,err %w", out, exitCode, ErrNetplanApply)
+// run
+//
+//	@Description: 不经过 shell 执行命令，避免网卡名、DNS 等参数被 shell 解析
+//	@param ctx
+//	@param executor 为 nil 时在本机执行
+//	@param name
+//	@param args
+//	@return error
+func run(ctx context.Context, executor bash.Executor, name string, args ...string) error {
+	if executor == nil {
+		executor = bash.NewLocal()
+	}
+	result := executor.Run(ctx, bash.NewArgvCommand(name, args...))
+	if result.Err != nil {
+		out := result.Stdout + result.Stderr
+		if result.ExitCode > 0 {
+			return fmt.Errorf("out: %s, exit.code: %d,err %w", out, result.ExitCode, ErrNetplanApply)
 		}
-		return fmt.Errorf("out: %s,err:%w", string(output), ErrNetplanApply)
+		return fmt.Errorf("out: %s,err:%w", out, ErrNetplanApply)
 	}
 	return nil
 }
@@ -2,7 +2,12 @@ package net
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/youcd/toolkit/bash"
 )
 
 func TestNetplan_SetNetWork(t *testing.T) {
@@ -16,3 +21,58 @@ func TestNetplan_SetNetWork(t *testing.T) {
 		netplan.Rollback(context.Background())
 	}
 }
+
+const testNetplanConfig = `network:
+  ethernets:
+    ens160:
+      dhcp4: false
+      addresses:
+        - 192.168.1.10/24
+  version: 2
+`
+
+func TestNetplan_SetNetWorkWithFake(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "00-installer-config.yaml")
+	err := os.WriteFile(configFile, []byte(testNetplanConfig), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	netplan, err := NewNetplan(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := bash.NewFake(bash.Fixture{Command: "netplan apply"})
+	netplan.Executor = fake
+
+	err = netplan.SetNetWork(context.Background(), []string{"192.168.111.9/23"}, []string{"8.8.8.8"}, "192.168.110.1", "ens160")
+	if err != nil {
+		t.Fatalf("SetNetWork() error = %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 1 || calls[0] != "netplan apply" {
+		t.Errorf("calls = %v", calls)
+	}
+
+	changed, err := NewNetplan(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ethernet := changed.NetworkObj.Ethernets["ens160"]
+	if ethernet.Gateway4 != "192.168.110.1" || ethernet.Addresses[0] != "192.168.111.9/23" || ethernet.Nameservers.Addresses[0] != "8.8.8.8" {
+		t.Errorf("ethernet = %+v", ethernet)
+	}
+
+	netplan.Executor = bash.NewFake(bash.Fixture{Command: "netplan apply"})
+	err = netplan.Rollback(context.Background())
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	data, _ := os.ReadFile(configFile)
+	if string(data) != testNetplanConfig {
+		t.Errorf("config after rollback = %s", data)
+	}
+
+	err = netplan.SetNetWork(context.Background(), nil, nil, "", "ens999")
+	if !errors.Is(err, ErrNotFoundCNI) {
+		t.Errorf("SetNetWork() error = %v, want ErrNotFoundCNI", err)
+	}
+}
@@ -1,12 +1,13 @@
 package nsenter
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"strconv"
+
+	"github.com/youcd/toolkit/bash"
 )
 
 // Config is the nsenter configuration used to generate
@@ -34,6 +35,8 @@ type Config struct {
 	UTS                 bool   // Enter UTS namespace
 	UTSFile             string // UTS namespace location, default to /proc/PID/ns/uts
 	WorkingDirectory    string // Set the working directory, default to target process working directory
+
+	Executor bash.Executor // Executor used by ExecuteContext, default to local
 }
 
 // ExecuteContext
@@ -47,23 +50,19 @@ type Config struct {
 //	@return string
 //	@return error
 func (c *Config) ExecuteContext(ctx context.Context, commd string, commdArgs ...string) (string, string, error) {
-	cmd := c.buildCommand(ctx)
-
-	var stdout bytes.Buffer
-
-	var stderr bytes.Buffer
-
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	cmd.Args = append(cmd.Args, commd)
-	cmd.Args = append(cmd.Args, commdArgs...)
+	executor := c.Executor
+	if executor == nil {
+		executor = bash.NewLocal()
+	}
+	args := append(c.buildArgs(), commd)
+	args = append(args, commdArgs...)
 
-	err := cmd.Run()
-	if err != nil {
-		return stdout.String(), stderr.String(), fmt.Errorf("cmd.Run(): %w", err)
+	result := executor.Run(ctx, bash.NewArgvCommand("nsenter", args...))
+	if result.Err != nil {
+		return result.Stdout, result.Stderr, fmt.Errorf("cmd.Run(): %w", result.Err)
 	}
 
-	return stdout.String(), stderr.String(), nil
+	return result.Stdout, result.Stderr, nil
 }
 
 // Enter
@@ -90,9 +89,16 @@ func (c *Config) Enter(ctx context.Context) error {
 //	@return *exec.Cmd
 //	@return error
 func (c *Config) buildCommand(ctx context.Context) *exec.Cmd {
-	args := []string{"nsenter"}
+	return exec.CommandContext(ctx, "nsenter", c.buildArgs()...)
+}
 
-	args = append(args, "--target", strconv.Itoa(c.Target))
+// buildArgs
+//
+//	@Description: 构建 nsenter 参数
+//	@receiver c
+//	@return []string
+func (c *Config) buildArgs() []string {
+	args := []string{"--target", strconv.Itoa(c.Target)}
 
 	if c.Cgroup {
 		args = append(args, "--cgroup")
@@ -171,5 +177,5 @@ func (c *Config) buildCommand(ctx context.Context) *exec.Cmd {
 		args = append(args, "--wd", c.WorkingDirectory)
 	}
 
-	return exec.CommandContext(ctx, "nsenter", args...)
+	return args
 }
@@ -0,0 +1,43 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/youcd/toolkit/bar"
+)
+
+// BarEvents
+//
+//	@Description: 订阅 bar 事件并转换为 JSON 字符串，可直接传给 Sse，使 web 端与命令行展示相同的进度
+//	@param ctx ctx 结束或 bar 停止后返回的 channel 会被关闭
+//	@param b
+//	@return chan string
+func BarEvents(ctx context.Context, b *bar.Bar) chan string {
+	events, cancel := b.Subscribe()
+	msg := make(chan string)
+	go func() {
+		defer close(msg)
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(event)
+				if err != nil {
+					continue
+				}
+				select {
+				case msg <- string(data):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return msg
+}
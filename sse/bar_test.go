@@ -0,0 +1,34 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/youcd/toolkit/bar"
+)
+
+func TestBarEvents(t *testing.T) {
+	b := bar.NewBarWithMode("部署", bar.ModePlain)
+	msg := BarEvents(context.Background(), b)
+	go func() {
+		b.AddStartBar("k8s", "部署 k8s...")
+		b.SetBarState("k8s", "k8s：OK", bar.StateSuccess)
+		b.Stop()
+	}()
+
+	var events []bar.Event
+	for s := range msg {
+		var event bar.Event
+		if err := json.Unmarshal([]byte(s), &event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 4 {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[1].Type != bar.EventState || events[1].Name != "k8s" || events[1].State != bar.StateSuccess {
+		t.Errorf("event = %+v", events[1])
+	}
+}
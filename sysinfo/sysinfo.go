@@ -5,7 +5,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -19,10 +18,10 @@ import (
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/host"
 	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/youcd/toolkit/bash"
 	"github.com/youcd/toolkit/net"
 	"github.com/youcd/toolkit/sysinfo/types"
 	"github.com/youcd/toolkit/systemd"
-	"go.uber.org/zap/buffer"
 	"golang.org/x/sys/unix"
 )
 
@@ -41,6 +40,20 @@ var (
 )
 
 func SysInfo(ctx context.Context, skipDiskPerformance bool, installDirs []string, services []string) (*types.Host, error) {
+	return SysInfoWithExecutor(ctx, bash.NewLocal(), skipDiskPerformance, installDirs, services)
+}
+
+// SysInfoWithExecutor
+//
+//	@Description: 与 SysInfo 相同，系统命令由 executor 执行，测试时可使用 bash.Fake
+//	@param ctx
+//	@param executor
+//	@param skipDiskPerformance
+//	@param installDirs
+//	@param services
+//	@return *types.Host
+//	@return error
+func SysInfoWithExecutor(ctx context.Context, executor bash.Executor, skipDiskPerformance bool, installDirs []string, services []string) (*types.Host, error) {
 	var errs []error
 	h := new(types.Host)
 
@@ -108,7 +121,7 @@ func SysInfo(ctx context.Context, skipDiskPerformance bool, installDirs []string
 	//  获取主机时间
 	timeFetch(h)
 
-	err = sudo(ctx, h)
+	err = sudo(ctx, executor, h)
 	if err != nil {
 		errs = append(errs, err)
 	}
@@ -170,30 +183,27 @@ var (
 // sudo
 //
 //	@Description:sudo检查
+//	@param executor
 //	@param t
 //	@return error
-func sudo(ctx context.Context, t *types.Host) error {
-	whoami, err := exec.CommandContext(ctx, "whoami").Output()
-	if err != nil {
-		return fmt.Errorf("whoami error: %w", err)
+func sudo(ctx context.Context, executor bash.Executor, t *types.Host) error {
+	whoami := executor.Run(ctx, bash.NewArgvCommand("whoami"))
+	if whoami.Err != nil {
+		return fmt.Errorf("whoami error: %w", whoami.Err)
 	}
 
-	s := strings.Trim(string(whoami), "\n")
+	s := strings.Trim(whoami.Stdout, "\n")
 
 	if strings.ToLower(s) == "root" {
 		t.Sudo = true
 		return nil
 	}
 
-	cmd := exec.CommandContext(ctx, "sudo", "-S", "-l")
-	cmd.Stdin = strings.NewReader(pwd + "\n")
-	var result buffer.Buffer
-	cmd.Stdout = &result
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("sudo -S -l: %w", err)
+	result := executor.Run(ctx, bash.NewArgvCommand("sudo", "-S", "-l").WithStdin(strings.NewReader(pwd+"\n")))
+	if result.Err != nil {
+		return fmt.Errorf("sudo -S -l: %w", result.Err)
 	}
-	trimSpace := strings.TrimSpace(result.String())
+	trimSpace := strings.TrimSpace(result.Stdout)
 	l := strings.Split(trimSpace, "\n")
 	if len(l) == 0 {
 		t.Sudo = true
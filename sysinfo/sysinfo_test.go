@@ -1,9 +1,11 @@
 package sysinfo
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
+	"github.com/youcd/toolkit/bash"
 	"github.com/youcd/toolkit/sysinfo/types"
 )
 
@@ -12,3 +14,44 @@ func Test_getSelinux(t *testing.T) {
 	getSelinux(h)
 	fmt.Println(h.Selinux)
 }
+
+func Test_sudo(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixtures []bash.Fixture
+		want     bool
+	}{
+		{
+			name:     "root",
+			fixtures: []bash.Fixture{{Command: "whoami", Stdout: "root\n"}},
+			want:     true,
+		},
+		{
+			name: "sudo all",
+			fixtures: []bash.Fixture{
+				{Command: "whoami", Stdout: "ops\n"},
+				{Command: "sudo -S -l", Stdout: "User ops may run the following commands on host:\n    (ALL) NOPASSWD: ALL\n"},
+			},
+			want: true,
+		},
+		{
+			name: "sudo limited",
+			fixtures: []bash.Fixture{
+				{Command: "whoami", Stdout: "ops\n"},
+				{Command: "sudo -S -l", Stdout: "User ops may run the following commands on host:\n    (root) /usr/bin/systemctl\n"},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := new(types.Host)
+			if err := sudo(context.Background(), bash.NewFake(tt.fixtures...), h); err != nil {
+				t.Fatal(err)
+			}
+			if h.Sudo != tt.want {
+				t.Errorf("Sudo = %v, want %v", h.Sudo, tt.want)
+			}
+		})
+	}
+}
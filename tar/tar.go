@@ -12,6 +12,7 @@ import (
 
 	"github.com/klauspost/compress/zstd"
 	"github.com/pkg/errors"
+	"github.com/youcd/toolkit/bar"
 	"github.com/youcd/toolkit/file"
 )
 
@@ -27,28 +28,52 @@ type Progress struct {
 //	@param dest
 //	@param progressChan
 //	@return error
+func ExtractTarZstOrGzipFile(src, dest string, progressChan chan string) error {
+	return extractTarZstOrGzipFile(src, dest, progressChan, nil)
+}
+
+// ExtractTarZstOrGzipFileWithProgress
 //
+//	@Description: 解压tar.zst或者tar.gz，并通过 progress 展示按压缩文件大小计算的进度
+//	@param src
+//	@param dest
+//	@param progress
+//	@return error
+func ExtractTarZstOrGzipFileWithProgress(src, dest string, progress *bar.Progress) error {
+	return extractTarZstOrGzipFile(src, dest, nil, progress)
+}
+
 //nolint:gocognit
-func ExtractTarZstOrGzipFile(src, dest string, progressChan chan string) error {
+func extractTarZstOrGzipFile(src, dest string, progressChan chan string, progress *bar.Progress) error {
 	fileHandle, err := os.Open(src)
 	if err != nil {
 		return fmt.Errorf("打开文件: %s,err：%w", src, err)
 	}
 	defer fileHandle.Close()
 
+	var fileReader io.Reader = fileHandle
+	if progress != nil {
+		stat, err := fileHandle.Stat()
+		if err != nil {
+			return fmt.Errorf("读取文件信息: %s,err：%w", src, err)
+		}
+		progress.SetTotal(stat.Size())
+		fileReader = progress.Reader(fileHandle)
+	}
+
 	var pg Progress
 	var reader io.Reader
 
 	switch strings.ToLower(filepath.Ext(src)) {
 	case ".gz":
-		gzipReader, err := gzip.NewReader(fileHandle)
+		gzipReader, err := gzip.NewReader(fileReader)
 		if err != nil {
 			return fmt.Errorf("读取文件: %s,err：%w", src, err)
 		}
 		reader = gzipReader
 		defer gzipReader.Close()
 	case ".zst":
-		zstReader, err := zstd.NewReader(fileHandle)
+		zstReader, err := zstd.NewReader(fileReader)
 		if err != nil {
 			return fmt.Errorf("读取文件: %s,err：%w", src, err)
 		}
@@ -1,8 +1,12 @@
 package tar
 
 import (
+	"bytes"
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/youcd/toolkit/bar"
 )
 
 func TestTarDirWithDirFunc(t *testing.T) {
@@ -30,3 +34,34 @@ func TestFileWithFunc(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestExtractTarZstOrGzipFileWithProgress(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "data")
+	err := os.MkdirAll(filepath.Join(src, "sub"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(src, "sub", "a.txt"), bytes.Repeat([]byte("a"), 4096), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(t.TempDir(), "data.tar.zst")
+	err = GzOrZstFileWithDirFunc(src, archive, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat, _ := os.Stat(archive)
+
+	b := bar.NewBarWithMode("解压", bar.ModePlain)
+	progress := b.AddProgressBar("extract", "解压 data.tar.zst", 0)
+	dest := t.TempDir()
+	err = ExtractTarZstOrGzipFileWithProgress(archive, dest, progress)
+	progress.Done("", bar.StateSuccess)
+	b.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Total() != stat.Size() || progress.Current() != stat.Size() {
+		t.Errorf("progress = %d/%d, file size = %d", progress.Current(), progress.Total(), stat.Size())
+	}
+}